@@ -36,51 +36,98 @@ func CheckPasswordHash(password, hash string) (bool, error) {
 	return match, nil
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	signingKey := []byte(tokenSecret)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    string(TokenTypeAccess),
-		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
-		Subject:   userID.String(),
-	})
-	signedToken, err := token.SignedString(signingKey)
+// MakeJWT issues an access token for userID signed with the signing key
+// of keys. The key's ID goes in the kid header and every token gets a
+// unique jti, so it can be revoked on its own. The returned claims are
+// those of the new token.
+func MakeJWT(userID uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, AccessClaims, error) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	claims := accessTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    string(TokenTypeAccess),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+			Subject:   userID.String(),
+		},
+		IssuedAtMs: now.UnixMilli(),
+	}
+	token := jwt.NewWithClaims(keys.signing.method, claims)
+	token.Header["kid"] = keys.signing.ID
+	signedToken, err := token.SignedString(keys.signing.private)
 	if err != nil {
-		return "", err
+		return "", AccessClaims{}, err
 	}
-	return signedToken, nil
+	return signedToken, AccessClaims{
+		ID:        claims.ID,
+		UserID:    userID,
+		IssuedAt:  now,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// accessTokenClaims adds iat_ms, the issue time in milliseconds, to the
+// registered claims. iat only has whole seconds, too coarse to tell a
+// token issued just after its user revoked every session from one issued
+// just before.
+type accessTokenClaims struct {
+	jwt.RegisteredClaims
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	type MyClaims struct {
-		Issuer    string    `json:"issuer"`
-		IssuedAt  time.Time `json:"issued_at"`
-		ExpiresAt time.Time `json:"expires_at"`
-		Subject   string    `json:"subject"`
-	}
-	claims := jwt.RegisteredClaims{}
-	parsedToken, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (any, error) {
-		return []byte(tokenSecret), nil
-	})
+// AccessClaims are the claims of a validated access token. ID is the jti.
+type AccessClaims struct {
+	ID        string
+	UserID    uuid.UUID
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+func ValidateJWT(tokenString string, keys *KeySet) (uuid.UUID, error) {
+	claims, err := ParseJWT(tokenString, keys)
 	if err != nil {
 		return uuid.Nil, err
 	}
+	return claims.UserID, nil
+}
+
+// ParseJWT validates an access token like ValidateJWT and also returns when
+// it was issued and when it expires.
+func ParseJWT(tokenString string, keys *KeySet) (AccessClaims, error) {
+	claims := accessTokenClaims{}
+	parsedToken, err := jwt.ParseWithClaims(tokenString, &claims, keys.verificationKey)
+	if err != nil {
+		return AccessClaims{}, err
+	}
 	userIDString, err := parsedToken.Claims.GetSubject()
 	if err != nil {
-		return uuid.Nil, err
+		return AccessClaims{}, err
 	}
 	issuer, err := parsedToken.Claims.GetIssuer()
 	if err != nil {
-		return uuid.Nil, err
+		return AccessClaims{}, err
 	}
 	if issuer != string(TokenTypeAccess) {
-		return uuid.Nil, errors.New("invalid issuer")
+		return AccessClaims{}, errors.New("invalid issuer")
 	}
 	id, err := uuid.Parse(userIDString)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID: %w", err)
+		return AccessClaims{}, fmt.Errorf("invalid user ID: %w", err)
+	}
+	if claims.ID == "" {
+		return AccessClaims{}, errors.New("token has no ID")
+	}
+
+	accessClaims := AccessClaims{ID: claims.ID, UserID: id}
+	if claims.IssuedAtMs != 0 {
+		accessClaims.IssuedAt = time.UnixMilli(claims.IssuedAtMs).UTC()
+	} else if claims.IssuedAt != nil {
+		accessClaims.IssuedAt = claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		accessClaims.ExpiresAt = claims.ExpiresAt.Time
 	}
-	return id, nil
+	return accessClaims, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
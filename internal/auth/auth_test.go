@@ -1,10 +1,13 @@
 package auth
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
 	"net/http"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
@@ -74,33 +77,90 @@ func TestCheckPasswordHash(t *testing.T) {
 
 func TestValidateJWT(t *testing.T) {
 	userID := uuid.New()
-	validToken, _ := MakeJWT(userID, "secret", time.Hour)
+	keys := newTestKeySet(t, "2025-01")
+	validToken, _, _ := MakeJWT(userID, keys, time.Hour)
+	expiredToken, _, _ := MakeJWT(userID, keys, -time.Minute)
+
+	// A correctly signed token without a jti cannot be revoked.
+	noIDToken := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.RegisteredClaims{
+		Issuer:    string(TokenTypeAccess),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   userID.String(),
+	})
+	noIDToken.Header["kid"] = "2025-01"
+	noIDTokenString, _ := noIDToken.SignedString(keys.keys["2025-01"].private)
+
+	// The same key ID backed by a different key must not verify.
+	otherKeys := newTestKeySet(t, "2025-01")
+
+	// A later key set that still accepts the old key, but signs with a new one.
+	_, newPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	newKey, _ := NewKey("2025-02", newPrivate)
+	rotatedKeys, _ := NewKeySet("2025-02", keys.keys["2025-01"], newKey)
+
+	// An HS256 token using the public key as its secret, as in algorithm
+	// confusion attacks.
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    string(TokenTypeAccess),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   userID.String(),
+	})
+	hmacToken.Header["kid"] = "2025-01"
+	hmacTokenString, _ := hmacToken.SignedString([]byte(keys.keys["2025-01"].public.(ed25519.PublicKey)))
 
 	tests := []struct {
 		name        string
 		tokenString string
-		tokenSecret string
+		keys        *KeySet
 		wantUserID  uuid.UUID
 		wantErr     bool
 	}{
 		{
 			name:        "Valid token",
 			tokenString: validToken,
-			tokenSecret: "secret",
+			keys:        keys,
+			wantUserID:  userID,
+			wantErr:     false,
+		},
+		{
+			name:        "Valid token after rotation",
+			tokenString: validToken,
+			keys:        rotatedKeys,
 			wantUserID:  userID,
 			wantErr:     false,
 		},
 		{
 			name:        "Invalid token",
 			tokenString: "invalid.token.string",
-			tokenSecret: "secret",
+			keys:        keys,
+			wantUserID:  uuid.Nil,
+			wantErr:     true,
+		},
+		{
+			name:        "Token without ID",
+			tokenString: noIDTokenString,
+			keys:        keys,
 			wantUserID:  uuid.Nil,
 			wantErr:     true,
 		},
 		{
-			name:        "Wrong secret",
+			name:        "Expired token",
+			tokenString: expiredToken,
+			keys:        keys,
+			wantUserID:  uuid.Nil,
+			wantErr:     true,
+		},
+		{
+			name:        "Wrong key",
 			tokenString: validToken,
-			tokenSecret: "wrong_secret",
+			keys:        otherKeys,
+			wantUserID:  uuid.Nil,
+			wantErr:     true,
+		},
+		{
+			name:        "HMAC with public key",
+			tokenString: hmacTokenString,
+			keys:        keys,
 			wantUserID:  uuid.Nil,
 			wantErr:     true,
 		},
@@ -108,7 +168,7 @@ func TestValidateJWT(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotUserID, err := ValidateJWT(tt.tokenString, tt.tokenSecret)
+			gotUserID, err := ValidateJWT(tt.tokenString, tt.keys)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -120,6 +180,23 @@ func TestValidateJWT(t *testing.T) {
 	}
 }
 
+func newTestKeySet(t *testing.T, kid string) *KeySet {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewKey(kid, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := NewKeySet(kid, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
 func TestGetBearerToken(t *testing.T) {
 	tests := []struct {
 		name      string
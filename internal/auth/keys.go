@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minRSAKeyBits = 2048
+
+// Key is a JWT key identified by its kid. Keys loaded from a private key
+// can sign and verify; keys loaded from a public key can only verify.
+type Key struct {
+	ID      string
+	method  jwt.SigningMethod
+	private any
+	public  any
+}
+
+// NewKey wraps an Ed25519 or RSA key, private or public, for use as kid.
+// Ed25519 keys sign with EdDSA and RSA keys with RS256.
+func NewKey(kid string, key any) (Key, error) {
+	if kid == "" {
+		return Key{}, errors.New("key ID is empty")
+	}
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return Key{ID: kid, method: jwt.SigningMethodEdDSA, private: k, public: k.Public()}, nil
+	case ed25519.PublicKey:
+		return Key{ID: kid, method: jwt.SigningMethodEdDSA, public: k}, nil
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return Key{}, fmt.Errorf("RSA key %s is shorter than %d bits", kid, minRSAKeyBits)
+		}
+		return Key{ID: kid, method: jwt.SigningMethodRS256, private: k, public: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return Key{}, fmt.Errorf("RSA key %s is shorter than %d bits", kid, minRSAKeyBits)
+		}
+		return Key{ID: kid, method: jwt.SigningMethodRS256, public: k}, nil
+	default:
+		return Key{}, fmt.Errorf("key %s: unsupported key type %T", kid, key)
+	}
+}
+
+// ParseKeyPEM reads a PKCS #8 or PKCS #1 private key, or a PKIX public
+// key, from PEM.
+func ParseKeyPEM(kid string, data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("key %s: no PEM block found", kid)
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return Key{}, fmt.Errorf("key %s: unsupported PEM block %q", kid, block.Type)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("key %s: %w", kid, err)
+	}
+	return NewKey(kid, key)
+}
+
+// KeySet holds the key access tokens are signed with and every key they
+// are accepted from.
+//
+// Keys are rotated without invalidating tokens in three steps:
+//
+//  1. Add the new key to every instance. It is published in the JWKS and
+//     accepted, but tokens are still signed with the old key.
+//  2. Once all instances and downstream verifiers know the new key, make
+//     it the signing key.
+//  3. When the last token signed with the old key has expired, remove the
+//     old key (or keep only its public half until then).
+type KeySet struct {
+	signing Key
+	keys    map[string]Key
+}
+
+// NewKeySet builds a KeySet from keys, signing with the one whose ID is
+// signingKID. The signing key must include its private half.
+func NewKeySet(signingKID string, keys ...Key) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]Key, len(keys))}
+	for _, key := range keys {
+		if _, ok := ks.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key ID %s", key.ID)
+		}
+		ks.keys[key.ID] = key
+	}
+
+	signing, ok := ks.keys[signingKID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %s not found", signingKID)
+	}
+	if signing.private == nil {
+		return nil, fmt.Errorf("signing key %s has no private key", signingKID)
+	}
+	ks.signing = signing
+	return ks, nil
+}
+
+// LoadKeySet reads every *.pem file in dir as a key whose ID is the file
+// name without the extension, and signs with signingKID.
+func LoadKeySet(dir, signingKID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []Key{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := ParseKeyPEM(kid, data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(signingKID, keys...)
+}
+
+// verificationKey picks the key a token claims to be signed with. The
+// token's algorithm must be the one that key signs with, so a public key
+// can never be used as an HMAC secret.
+func (ks *KeySet) verificationKey(token *jwt.Token) (any, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, errors.New("token has no key ID")
+	}
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %s", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("key %s does not sign with %s", kid, token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public halves of all keys, ordered by ID, for services
+// that verify our tokens themselves.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.public.(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+	return jwks
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseKeyPEM(t *testing.T) {
+	edPublic, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	rsaPrivate, _ := rsa.GenerateKey(rand.Reader, 2048)
+	weakRSA, _ := rsa.GenerateKey(rand.Reader, 1024)
+
+	encode := func(blockType string, der []byte) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	}
+	pkcs8 := func(key any) []byte {
+		der, _ := x509.MarshalPKCS8PrivateKey(key)
+		return encode("PRIVATE KEY", der)
+	}
+	pkix := func(key any) []byte {
+		der, _ := x509.MarshalPKIXPublicKey(key)
+		return encode("PUBLIC KEY", der)
+	}
+
+	tests := []struct {
+		name       string
+		data       []byte
+		wantAlg    string
+		wantSigner bool
+		wantErr    bool
+	}{
+		{name: "Ed25519 private", data: pkcs8(edPrivate), wantAlg: "EdDSA", wantSigner: true},
+		{name: "Ed25519 public", data: pkix(edPublic), wantAlg: "EdDSA"},
+		{name: "RSA private PKCS8", data: pkcs8(rsaPrivate), wantAlg: "RS256", wantSigner: true},
+		{name: "RSA private PKCS1", data: encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaPrivate)), wantAlg: "RS256", wantSigner: true},
+		{name: "RSA public", data: pkix(&rsaPrivate.PublicKey), wantAlg: "RS256"},
+		{name: "RSA too short", data: pkcs8(weakRSA), wantErr: true},
+		{name: "Certificate", data: encode("CERTIFICATE", []byte{1, 2, 3}), wantErr: true},
+		{name: "Not PEM", data: []byte("secret"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseKeyPEM("k1", tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKeyPEM() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if key.method.Alg() != tt.wantAlg {
+				t.Errorf("ParseKeyPEM() alg = %s, want %s", key.method.Alg(), tt.wantAlg)
+			}
+			if (key.private != nil) != tt.wantSigner {
+				t.Errorf("ParseKeyPEM() can sign = %v, want %v", key.private != nil, tt.wantSigner)
+			}
+		})
+	}
+}
+
+func TestNewKeySet(t *testing.T) {
+	edPublic, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	signer, _ := NewKey("a", edPrivate)
+	verifier, _ := NewKey("b", edPublic)
+	duplicate, _ := NewKey("a", edPublic)
+
+	tests := []struct {
+		name       string
+		signingKID string
+		keys       []Key
+		wantErr    bool
+	}{
+		{name: "Signing key with a retired key", signingKID: "a", keys: []Key{signer, verifier}},
+		{name: "Missing signing key", signingKID: "c", keys: []Key{signer, verifier}, wantErr: true},
+		{name: "Public-only signing key", signingKID: "b", keys: []Key{signer, verifier}, wantErr: true},
+		{name: "Duplicate key ID", signingKID: "a", keys: []Key{signer, duplicate}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeySet(tt.signingKID, tt.keys...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewKeySet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	_, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	rsaPrivate, _ := rsa.GenerateKey(rand.Reader, 2048)
+	edKey, _ := NewKey("ed", edPrivate)
+	rsaKey, _ := NewKey("rsa", &rsaPrivate.PublicKey)
+	keys, err := NewKeySet("ed", rsaKey, edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := keys.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS() has %d keys, want 2", len(jwks.Keys))
+	}
+	ed, rsaJWK := jwks.Keys[0], jwks.Keys[1]
+	if ed.Kid != "ed" || ed.Kty != "OKP" || ed.Crv != "Ed25519" || ed.Alg != "EdDSA" || ed.X == "" {
+		t.Errorf("JWKS() Ed25519 key = %+v", ed)
+	}
+	if rsaJWK.Kid != "rsa" || rsaJWK.Kty != "RSA" || rsaJWK.Alg != "RS256" || rsaJWK.E != "AQAB" || rsaJWK.N == "" {
+		t.Errorf("JWKS() RSA key = %+v", rsaJWK)
+	}
+
+	// Tokens signed with the set verify against it.
+	userID := uuid.New()
+	token, claims, err := MakeJWT(userID, keys, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseJWT(token, keys)
+	if err != nil || got.ID != claims.ID || got.UserID != userID || !got.IssuedAt.Equal(claims.IssuedAt) || !got.ExpiresAt.Equal(claims.ExpiresAt) {
+		t.Errorf("ParseJWT() = %+v, %v, want %+v", got, err, claims)
+	}
+}
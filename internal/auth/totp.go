@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpPeriod = 30
+	// totpSkew is how many periods of clock drift either way are allowed.
+	totpSkew = 1
+
+	recoveryCodeBytes = 5
+)
+
+var totpOpts = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// GenerateTOTPKey creates a TOTP secret for accountName. It returns the
+// base32 secret and the otpauth:// URI that authenticator apps import,
+// usually from a QR code.
+func GenerateTOTPKey(issuer, accountName string) (secret, uri string, err error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: accountName,
+		Period:      totpPeriod,
+		Digits:      totpOpts.Digits,
+		Algorithm:   totpOpts.Algorithm,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return key.Secret(), key.URL(), nil
+}
+
+// ValidateTOTP checks a code against secret at now. Codes are only
+// accepted for time steps after lastStep, so each code works once; on
+// success the step it matched is returned to be stored as the new
+// lastStep.
+func ValidateTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totpOpts)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// MakeRecoveryCode returns a random one-time code like "3f9a1-0c7be" for
+// signing in without the authenticator.
+func MakeRecoveryCode() (string, error) {
+	randBytes := make([]byte, recoveryCodeBytes)
+	_, err := rand.Read(randBytes)
+	if err != nil {
+		return "", err
+	}
+	code := hex.EncodeToString(randBytes)
+	return code[:5] + "-" + code[5:], nil
+}
+
+// IsRecoveryCode reports whether a normalized code has the shape of a
+// recovery code made by MakeRecoveryCode.
+func IsRecoveryCode(code string) bool {
+	if len(code) != 2*recoveryCodeBytes {
+		return false
+	}
+	_, err := hex.DecodeString(code)
+	return err == nil
+}
+
+// NormalizeRecoveryCode puts a recovery code as typed by a user in the
+// form it was hashed in: lower case, without spaces or dashes.
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+func TestValidateTOTP(t *testing.T) {
+	secret, uri, err := GenerateTOTPKey("Chirpy", "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri == "" {
+		t.Fatal("GenerateTOTPKey() returned an empty URI")
+	}
+
+	now := time.Unix(1700000000, 0)
+	step := now.Unix() / totpPeriod
+	codeAt := func(offset int64) string {
+		code, err := totp.GenerateCodeCustom(secret, time.Unix((step+offset)*totpPeriod, 0), totpOpts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{
+			name:     "Current code",
+			code:     codeAt(0),
+			wantStep: step,
+			wantOK:   true,
+		},
+		{
+			name:     "Previous period",
+			code:     codeAt(-1),
+			wantStep: step - 1,
+			wantOK:   true,
+		},
+		{
+			name:     "Next period",
+			code:     codeAt(1),
+			wantStep: step + 1,
+			wantOK:   true,
+		},
+		{
+			name:   "Too old",
+			code:   codeAt(-2),
+			wantOK: false,
+		},
+		{
+			name:     "Already used",
+			code:     codeAt(0),
+			lastStep: step,
+			wantOK:   false,
+		},
+		{
+			name:   "Wrong code",
+			code:   "abcdef",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := ValidateTOTP(secret, tt.code, now, tt.lastStep)
+			if ok != tt.wantOK {
+				t.Fatalf("ValidateTOTP() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && tt.wantStep != 0 && gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP() step = %d, want %d", gotStep, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	code, err := MakeRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 11 || code[5] != '-' {
+		t.Errorf("MakeRecoveryCode() = %q", code)
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "3f9a1-0c7be", want: "3f9a10c7be"},
+		{input: "3F9A1 0C7BE", want: "3f9a10c7be"},
+		{input: "3f9a10c7be", want: "3f9a10c7be"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeRecoveryCode(tt.input); got != tt.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+		if !IsRecoveryCode(tt.want) {
+			t.Errorf("IsRecoveryCode(%q) = false, want true", tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "3f9a10c7b", "3f9a10c7be0", "3f9a10c7bz"} {
+		if IsRecoveryCode(input) {
+			t.Errorf("IsRecoveryCode(%q) = true, want false", input)
+		}
+	}
+}
@@ -7,23 +7,35 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps(body, user_id)
-VALUES ($1,$2)
-RETURNING id, created_at, updated_at, body, user_id
+INSERT INTO chirps(body, user_id, in_reply_to_id, quote_of_id, visibility)
+VALUES ($1,$2,$3,$4,$5)
+RETURNING id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, reply_count, deleted_at, like_count, quote_of_id, visibility, pinned_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body        string
+	UserID      uuid.UUID
+	InReplyToID uuid.NullUUID
+	QuoteOfID   uuid.NullUUID
+	Visibility  string
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyToID,
+		arg.QuoteOfID,
+		arg.Visibility,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -31,6 +43,14 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyToID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.QuoteOfID,
+		&i.Visibility,
+		&i.PinnedAt,
 	)
 	return i, err
 }
@@ -46,14 +66,31 @@ func (q *Queries) DeleteChirpById(ctx context.Context, id uuid.UUID) error {
 }
 
 const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-ORDER BY
-    CASE WHEN COALESCE($1, 'asc') = 'desc' THEN created_at END DESC,
-    CASE WHEN COALESCE($1, 'asc') = 'asc' THEN created_at END ASC
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, reply_count, deleted_at, like_count, quote_of_id, visibility, pinned_at FROM chirps
+WHERE deleted_at IS NULL
+    AND (
+        $1::timestamp IS NULL
+        OR (created_at, id) > ($1::timestamp, $2::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $3::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT $4
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context, dollar_1 interface{}) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps, dollar_1)
+type GetAllChirpsParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) GetAllChirps(ctx context.Context, arg GetAllChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirps,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -67,6 +104,75 @@ func (q *Queries) GetAllChirps(ctx context.Context, dollar_1 interface{}) ([]Chi
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.InReplyToID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.QuoteOfID,
+			&i.Visibility,
+			&i.PinnedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllChirpsDesc = `-- name: GetAllChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, reply_count, deleted_at, like_count, quote_of_id, visibility, pinned_at FROM chirps
+WHERE deleted_at IS NULL
+    AND (
+        $1::timestamp IS NULL
+        OR (created_at, id) < ($1::timestamp, $2::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $3::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type GetAllChirpsDescParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) GetAllChirpsDesc(ctx context.Context, arg GetAllChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirpsDesc,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.InReplyToID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.QuoteOfID,
+			&i.Visibility,
+			&i.PinnedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -82,7 +188,7 @@ func (q *Queries) GetAllChirps(ctx context.Context, dollar_1 interface{}) ([]Chi
 }
 
 const getChirpByID = `-- name: GetChirpByID :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, reply_count, deleted_at, like_count, quote_of_id, visibility, pinned_at FROM chirps
 WHERE id = $1
 `
 
@@ -95,25 +201,293 @@ func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyToID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.QuoteOfID,
+		&i.Visibility,
+		&i.PinnedAt,
+	)
+	return i, err
+}
+
+const getChirpByIDForUpdate = `-- name: GetChirpByIDForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, reply_count, deleted_at, like_count, quote_of_id, visibility, pinned_at FROM chirps
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetChirpByIDForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpByIDForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyToID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.QuoteOfID,
+		&i.Visibility,
+		&i.PinnedAt,
 	)
 	return i, err
 }
 
+const getChirpViewers = `-- name: GetChirpViewers :many
+SELECT viewer_id::uuid FROM chirps, UNNEST($2::uuid[]) AS viewer_id
+WHERE chirps.id = $1
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, viewer_id)
+`
+
+type GetChirpViewersParams struct {
+	ChirpID uuid.UUID
+	UserIds []uuid.UUID
+}
+
+func (q *Queries) GetChirpViewers(ctx context.Context, arg GetChirpViewersParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpViewers, arg.ChirpID, pq.Array(arg.UserIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var viewer_id uuid.UUID
+		if err := rows.Scan(&viewer_id); err != nil {
+			return nil, err
+		}
+		items = append(items, viewer_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirpsByAuthorID = `-- name: GetChirpsByAuthorID :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE user_id = $1
-ORDER BY
-    CASE WHEN COALESCE($2, 'asc') = 'desc' THEN created_at END DESC,
-    CASE WHEN COALESCE($2, 'asc') = 'asc' THEN created_at END ASC
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.in_reply_to_id, chirps.reply_count, chirps.deleted_at, chirps.like_count, chirps.quote_of_id, chirps.visibility, chirps.pinned_at, feed.item_id, feed.item_created_at, feed.rechirped_by
+FROM (
+    SELECT authored.id AS item_id, authored.created_at AS item_created_at, authored.id AS chirp_id, NULL::uuid AS rechirped_by
+    FROM chirps authored
+    WHERE authored.user_id = $1
+        AND authored.pinned_at IS NULL
+    UNION ALL
+    SELECT rechirps.id, rechirps.created_at, rechirps.chirp_id, rechirps.user_id
+    FROM rechirps
+    WHERE rechirps.user_id = $1
+) feed
+JOIN chirps ON chirps.id = feed.chirp_id
+WHERE chirps.deleted_at IS NULL
+    AND (
+        $2::timestamp IS NULL
+        OR (feed.item_created_at, feed.item_id) > ($2::timestamp, $3::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $4::uuid)
+ORDER BY feed.item_created_at ASC, feed.item_id ASC
+LIMIT $5
 `
 
 type GetChirpsByAuthorIDParams struct {
-	UserID  uuid.UUID
-	Column2 interface{}
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	PageLimit       int32
+}
+
+type GetChirpsByAuthorIDRow struct {
+	Chirp         Chirp
+	ItemID        uuid.UUID
+	ItemCreatedAt time.Time
+	RechirpedBy   uuid.NullUUID
 }
 
-func (q *Queries) GetChirpsByAuthorID(ctx context.Context, arg GetChirpsByAuthorIDParams) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByAuthorID, arg.UserID, arg.Column2)
+func (q *Queries) GetChirpsByAuthorID(ctx context.Context, arg GetChirpsByAuthorIDParams) ([]GetChirpsByAuthorIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByAuthorID,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpsByAuthorIDRow
+	for rows.Next() {
+		var i GetChirpsByAuthorIDRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.SearchVector,
+			&i.Chirp.InReplyToID,
+			&i.Chirp.ReplyCount,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.LikeCount,
+			&i.Chirp.QuoteOfID,
+			&i.Chirp.Visibility,
+			&i.Chirp.PinnedAt,
+			&i.ItemID,
+			&i.ItemCreatedAt,
+			&i.RechirpedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByAuthorIDDesc = `-- name: GetChirpsByAuthorIDDesc :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.in_reply_to_id, chirps.reply_count, chirps.deleted_at, chirps.like_count, chirps.quote_of_id, chirps.visibility, chirps.pinned_at, feed.item_id, feed.item_created_at, feed.rechirped_by
+FROM (
+    SELECT authored.id AS item_id, authored.created_at AS item_created_at, authored.id AS chirp_id, NULL::uuid AS rechirped_by
+    FROM chirps authored
+    WHERE authored.user_id = $1
+        AND authored.pinned_at IS NULL
+    UNION ALL
+    SELECT rechirps.id, rechirps.created_at, rechirps.chirp_id, rechirps.user_id
+    FROM rechirps
+    WHERE rechirps.user_id = $1
+) feed
+JOIN chirps ON chirps.id = feed.chirp_id
+WHERE chirps.deleted_at IS NULL
+    AND (
+        $2::timestamp IS NULL
+        OR (feed.item_created_at, feed.item_id) < ($2::timestamp, $3::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $4::uuid)
+ORDER BY feed.item_created_at DESC, feed.item_id DESC
+LIMIT $5
+`
+
+type GetChirpsByAuthorIDDescParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	PageLimit       int32
+}
+
+type GetChirpsByAuthorIDDescRow struct {
+	Chirp         Chirp
+	ItemID        uuid.UUID
+	ItemCreatedAt time.Time
+	RechirpedBy   uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsByAuthorIDDesc(ctx context.Context, arg GetChirpsByAuthorIDDescParams) ([]GetChirpsByAuthorIDDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByAuthorIDDesc,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpsByAuthorIDDescRow
+	for rows.Next() {
+		var i GetChirpsByAuthorIDDescRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.SearchVector,
+			&i.Chirp.InReplyToID,
+			&i.Chirp.ReplyCount,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.LikeCount,
+			&i.Chirp.QuoteOfID,
+			&i.Chirp.Visibility,
+			&i.Chirp.PinnedAt,
+			&i.ItemID,
+			&i.ItemCreatedAt,
+			&i.RechirpedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVisibleChirpByID = `-- name: GetVisibleChirpByID :one
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, reply_count, deleted_at, like_count, quote_of_id, visibility, pinned_at FROM chirps
+WHERE id = $1
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $2::uuid)
+`
+
+type GetVisibleChirpByIDParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetVisibleChirpByID(ctx context.Context, arg GetVisibleChirpByIDParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getVisibleChirpByID, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyToID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.QuoteOfID,
+		&i.Visibility,
+		&i.PinnedAt,
+	)
+	return i, err
+}
+
+const getVisibleChirpsByIDs = `-- name: GetVisibleChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, reply_count, deleted_at, like_count, quote_of_id, visibility, pinned_at FROM chirps
+WHERE id = ANY($1::uuid[])
+    AND deleted_at IS NULL
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $2::uuid)
+`
+
+type GetVisibleChirpsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetVisibleChirpsByIDs(ctx context.Context, arg GetVisibleChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getVisibleChirpsByIDs, pq.Array(arg.Ids), arg.ViewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -127,6 +501,14 @@ func (q *Queries) GetChirpsByAuthorID(ctx context.Context, arg GetChirpsByAuthor
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.InReplyToID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.QuoteOfID,
+			&i.Visibility,
+			&i.PinnedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -140,3 +522,50 @@ func (q *Queries) GetChirpsByAuthorID(ctx context.Context, arg GetChirpsByAuthor
 	}
 	return items, nil
 }
+
+const tombstoneChirp = `-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '',
+    deleted_at = NOW(),
+    pinned_at = NULL
+WHERE id = $1
+`
+
+func (q *Queries) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirp, id)
+	return err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, reply_count, deleted_at, like_count, quote_of_id, visibility, pinned_at
+`
+
+type UpdateChirpBodyParams struct {
+	ID   uuid.UUID
+	Body string
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyToID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.QuoteOfID,
+		&i.Visibility,
+		&i.PinnedAt,
+	)
+	return i, err
+}
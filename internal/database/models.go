@@ -11,28 +11,222 @@ import (
 	"github.com/google/uuid"
 )
 
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Body         string
+	UserID       uuid.UUID
+	SearchVector interface{}
+	InReplyToID  uuid.NullUUID
+	ReplyCount   int32
+	DeletedAt    sql.NullTime
+	LikeCount    int32
+	QuoteOfID    uuid.NullUUID
+	Visibility   string
+	PinnedAt     sql.NullTime
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	Body       string
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+type ChirpTag struct {
+	ChirpID   uuid.UUID
+	TagID     uuid.UUID
+	CreatedAt time.Time
+}
+
+type Conversation struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+	CreatedBy uuid.UUID
+	IsGroup   bool
+}
+
+type ConversationParticipant struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+}
+
+type Draft struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Body        string
+	InReplyToID uuid.NullUUID
+	QuoteOfID   uuid.NullUUID
+	Visibility  string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type FollowRequest struct {
+	RequesterID uuid.UUID
+	TargetID    uuid.UUID
+	CreatedAt   time.Time
+}
+
+type Like struct {
 	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
 }
 
-type RefreshToken struct {
-	Token     string
+type List struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+}
+
+type ListMember struct {
+	ListID    uuid.UUID
 	UserID    uuid.UUID
-	ExpiresAt time.Time
-	RevokedAt sql.NullTime
+	CreatedAt time.Time
 }
 
-type User struct {
+type MFAChallenge struct {
+	Token      string
+	UserID     uuid.UUID
+	DeviceName string
+	Attempts   int32
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+}
+
+type Mention struct {
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	StartOffset int32
+	EndOffset   int32
+	CreatedAt   time.Time
+}
+
+type Message struct {
 	ID             uuid.UUID
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
 	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	ActorID   uuid.NullUUID
+	Type      string
+	ChirpID   uuid.NullUUID
+	CreatedAt time.Time
+	ReadAt    sql.NullTime
+}
+
+type Rechirp struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type RecoveryCode struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type RefreshToken struct {
+	Token                string
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	UserID               uuid.UUID
+	ExpiresAt            time.Time
+	RevokedAt            sql.NullTime
+	FamilyID             uuid.UUID
+	DeviceName           string
+	UserAgent            string
+	IP                   string
+	LastUsedAt           time.Time
+	AccessTokenJTI       sql.NullString
+	AccessTokenExpiresAt sql.NullTime
+	RotatedAt            sql.NullTime
+}
+
+type RevokedAccessToken struct {
+	JTI       string
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
+
+type ScheduledChirp struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Body        string
+	InReplyToID uuid.NullUUID
+	QuoteOfID   uuid.NullUUID
+	Visibility  string
+	PublishAt   time.Time
+	Failure     sql.NullString
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type Tag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Name      string
+}
+
+type User struct {
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Email             string
+	HashedPassword    string
+	IsChirpyRed       bool
+	Handle            sql.NullString
+	DisplayName       sql.NullString
+	Bio               sql.NullString
+	AvatarURL         sql.NullString
+	IsPrivate         bool
+	TokensValidAfter  sql.NullTime
+	TOTPSecret        sql.NullString
+	TOTPEnabled       bool
+	TOTPLastStep      int64
+	MFAFailedAttempts int32
+	MFALockedUntil    sql.NullTime
+	SuspendedAt       sql.NullTime
 }
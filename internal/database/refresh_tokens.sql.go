@@ -14,15 +14,21 @@ import (
 )
 
 const createRefreshToken = `-- name: CreateRefreshToken :exec
-INSERT INTO refresh_tokens(token, user_id, expires_at, revoked_at)
-VALUES($1, $2, $3, $4)
+INSERT INTO refresh_tokens(token, user_id, expires_at, revoked_at, family_id, device_name, user_agent, ip, access_token_jti, access_token_expires_at)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 `
 
 type CreateRefreshTokenParams struct {
-	Token     string
-	UserID    uuid.UUID
-	ExpiresAt time.Time
-	RevokedAt sql.NullTime
+	Token                string
+	UserID               uuid.UUID
+	ExpiresAt            time.Time
+	RevokedAt            sql.NullTime
+	FamilyID             uuid.UUID
+	DeviceName           string
+	UserAgent            string
+	IP                   string
+	AccessTokenJTI       sql.NullString
+	AccessTokenExpiresAt sql.NullTime
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) error {
@@ -31,12 +37,30 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 		arg.UserID,
 		arg.ExpiresAt,
 		arg.RevokedAt,
+		arg.FamilyID,
+		arg.DeviceName,
+		arg.UserAgent,
+		arg.IP,
+		arg.AccessTokenJTI,
+		arg.AccessTokenExpiresAt,
 	)
 	return err
 }
 
+const getFamilyIDByAccessTokenJTI = `-- name: GetFamilyIDByAccessTokenJTI :one
+SELECT family_id FROM refresh_tokens
+WHERE access_token_jti = $1
+`
+
+func (q *Queries) GetFamilyIDByAccessTokenJTI(ctx context.Context, accessTokenJti sql.NullString) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getFamilyIDByAccessTokenJTI, accessTokenJti)
+	var family_id uuid.UUID
+	err := row.Scan(&family_id)
+	return family_id, err
+}
+
 const getRefreshToken = `-- name: GetRefreshToken :one
-SELECT token, created_at, updated_at, user_id, expires_at, revoked_at FROM refresh_tokens
+SELECT token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, device_name, user_agent, ip, last_used_at, access_token_jti, access_token_expires_at, rotated_at FROM refresh_tokens
 WHERE token = $1
 `
 
@@ -50,10 +74,119 @@ func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshTok
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.DeviceName,
+		&i.UserAgent,
+		&i.IP,
+		&i.LastUsedAt,
+		&i.AccessTokenJTI,
+		&i.AccessTokenExpiresAt,
+		&i.RotatedAt,
 	)
 	return i, err
 }
 
+const getSessionsForUser = `-- name: GetSessionsForUser :many
+SELECT family_id, device_name, user_agent, ip, last_used_at, expires_at,
+    (
+        SELECT MIN(family.created_at) FROM refresh_tokens family
+        WHERE family.family_id = refresh_tokens.family_id
+    )::timestamp AS started_at
+FROM refresh_tokens
+WHERE user_id = $1
+    AND revoked_at IS NULL
+    AND expires_at > NOW()
+ORDER BY last_used_at DESC, family_id DESC
+`
+
+type GetSessionsForUserRow struct {
+	FamilyID   uuid.UUID
+	DeviceName string
+	UserAgent  string
+	IP         string
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	StartedAt  time.Time
+}
+
+func (q *Queries) GetSessionsForUser(ctx context.Context, userID uuid.UUID) ([]GetSessionsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSessionsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSessionsForUserRow
+	for rows.Next() {
+		var i GetSessionsForUserRow
+		if err := rows.Scan(
+			&i.FamilyID,
+			&i.DeviceName,
+			&i.UserAgent,
+			&i.IP,
+			&i.LastUsedAt,
+			&i.ExpiresAt,
+			&i.StartedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeAllSessions = `-- name: RevokeAllSessions :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeAllSessions, userID)
+	return err
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, familyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeSession = `-- name: RevokeSession :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeSessionParams struct {
+	FamilyID uuid.UUID
+	UserID   uuid.UUID
+}
+
+func (q *Queries) RevokeSession(ctx context.Context, arg RevokeSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeSession, arg.FamilyID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const revokeToken = `-- name: RevokeToken :exec
 UPDATE refresh_tokens
 SET revoked_at = NOW(),
@@ -65,3 +198,93 @@ func (q *Queries) RevokeToken(ctx context.Context, token string) error {
 	_, err := q.db.ExecContext(ctx, revokeToken, token)
 	return err
 }
+
+const revokeUserSessionsExcept = `-- name: RevokeUserSessionsExcept :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1
+    AND revoked_at IS NULL
+    AND family_id IS DISTINCT FROM $2::uuid
+`
+
+type RevokeUserSessionsExceptParams struct {
+	UserID       uuid.UUID
+	KeepFamilyID uuid.NullUUID
+}
+
+func (q *Queries) RevokeUserSessionsExcept(ctx context.Context, arg RevokeUserSessionsExceptParams) error {
+	_, err := q.db.ExecContext(ctx, revokeUserSessionsExcept, arg.UserID, arg.KeepFamilyID)
+	return err
+}
+
+const rotateRefreshToken = `-- name: RotateRefreshToken :one
+WITH rotated AS (
+    UPDATE refresh_tokens
+    SET revoked_at = NOW(),
+        rotated_at = NOW(),
+        updated_at = NOW()
+    WHERE token = $1
+        AND revoked_at IS NULL
+        AND expires_at > NOW()
+    RETURNING user_id, family_id, device_name
+)
+INSERT INTO refresh_tokens(token, user_id, expires_at, family_id, device_name, user_agent, ip)
+SELECT $2::text, user_id, $3::timestamp, family_id, device_name, $4::text, $5::text
+FROM rotated
+RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, device_name, user_agent, ip, last_used_at, access_token_jti, access_token_expires_at, rotated_at
+`
+
+type RotateRefreshTokenParams struct {
+	OldToken  string
+	NewToken  string
+	ExpiresAt time.Time
+	UserAgent string
+	IP        string
+}
+
+func (q *Queries) RotateRefreshToken(ctx context.Context, arg RotateRefreshTokenParams) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, rotateRefreshToken,
+		arg.OldToken,
+		arg.NewToken,
+		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.IP,
+	)
+	var i RefreshToken
+	err := row.Scan(
+		&i.Token,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.FamilyID,
+		&i.DeviceName,
+		&i.UserAgent,
+		&i.IP,
+		&i.LastUsedAt,
+		&i.AccessTokenJTI,
+		&i.AccessTokenExpiresAt,
+		&i.RotatedAt,
+	)
+	return i, err
+}
+
+const setRefreshTokenAccessToken = `-- name: SetRefreshTokenAccessToken :exec
+UPDATE refresh_tokens
+SET access_token_jti = $2,
+    access_token_expires_at = $3
+WHERE token = $1
+`
+
+type SetRefreshTokenAccessTokenParams struct {
+	Token                string
+	AccessTokenJTI       sql.NullString
+	AccessTokenExpiresAt sql.NullTime
+}
+
+func (q *Queries) SetRefreshTokenAccessToken(ctx context.Context, arg SetRefreshTokenAccessTokenParams) error {
+	_, err := q.db.ExecContext(ctx, setRefreshTokenAccessToken, arg.Token, arg.AccessTokenJTI, arg.AccessTokenExpiresAt)
+	return err
+}
@@ -7,23 +7,36 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users(email, hashed_password)
-VALUES ($1, $2)
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+INSERT INTO users(email, hashed_password, handle, display_name, bio, avatar_url)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, is_private, tokens_valid_after, totp_secret, totp_enabled, totp_last_step, mfa_failed_attempts, mfa_locked_until, suspended_at
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
+	DisplayName    sql.NullString
+	Bio            sql.NullString
+	AvatarURL      sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarURL,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -32,12 +45,50 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarURL,
+		&i.IsPrivate,
+		&i.TokensValidAfter,
+		&i.TOTPSecret,
+		&i.TOTPEnabled,
+		&i.TOTPLastStep,
+		&i.MFAFailedAttempts,
+		&i.MFALockedUntil,
+		&i.SuspendedAt,
 	)
 	return i, err
 }
 
+const disableUserTOTP = `-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL,
+    totp_enabled = FALSE,
+    totp_last_step = 0,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) DisableUserTOTP(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, disableUserTOTP, id)
+	return err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :exec
+UPDATE users
+SET totp_enabled = TRUE,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, enableUserTOTP, id)
+	return err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, is_private, tokens_valid_after, totp_secret, totp_enabled, totp_last_step, mfa_failed_attempts, mfa_locked_until, suspended_at FROM users
 WHERE email = $1
 `
 
@@ -51,27 +102,326 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarURL,
+		&i.IsPrivate,
+		&i.TokensValidAfter,
+		&i.TOTPSecret,
+		&i.TOTPEnabled,
+		&i.TOTPLastStep,
+		&i.MFAFailedAttempts,
+		&i.MFALockedUntil,
+		&i.SuspendedAt,
 	)
 	return i, err
 }
 
-const updateUser = `-- name: UpdateUser :one
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, is_private, tokens_valid_after, totp_secret, totp_enabled, totp_last_step, mfa_failed_attempts, mfa_locked_until, suspended_at FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarURL,
+		&i.IsPrivate,
+		&i.TokensValidAfter,
+		&i.TOTPSecret,
+		&i.TOTPEnabled,
+		&i.TOTPLastStep,
+		&i.MFAFailedAttempts,
+		&i.MFALockedUntil,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const getUserByIDForUpdate = `-- name: GetUserByIDForUpdate :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, is_private, tokens_valid_after, totp_secret, totp_enabled, totp_last_step, mfa_failed_attempts, mfa_locked_until, suspended_at FROM users
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetUserByIDForUpdate(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByIDForUpdate, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarURL,
+		&i.IsPrivate,
+		&i.TokensValidAfter,
+		&i.TOTPSecret,
+		&i.TOTPEnabled,
+		&i.TOTPLastStep,
+		&i.MFAFailedAttempts,
+		&i.MFALockedUntil,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const getUserProfileByHandle = `-- name: GetUserProfileByHandle :one
+SELECT users.id, users.created_at, users.handle, users.display_name, users.bio, users.avatar_url, users.is_chirpy_red, users.is_private,
+    (
+        SELECT COUNT(*) FROM chirps
+        WHERE chirps.user_id = users.id
+            AND chirps.deleted_at IS NULL
+    ) AS chirp_count,
+    (
+        SELECT COUNT(*) FROM follows
+        WHERE follows.followee_id = users.id
+    ) AS follower_count,
+    (
+        SELECT COUNT(*) FROM follows
+        WHERE follows.follower_id = users.id
+    ) AS following_count
+FROM users
+WHERE LOWER(users.handle) = LOWER($1)
+`
+
+type GetUserProfileByHandleRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	Handle         sql.NullString
+	DisplayName    sql.NullString
+	Bio            sql.NullString
+	AvatarURL      sql.NullString
+	IsChirpyRed    bool
+	IsPrivate      bool
+	ChirpCount     int64
+	FollowerCount  int64
+	FollowingCount int64
+}
+
+func (q *Queries) GetUserProfileByHandle(ctx context.Context, handle string) (GetUserProfileByHandleRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserProfileByHandle, handle)
+	var i GetUserProfileByHandleRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarURL,
+		&i.IsChirpyRed,
+		&i.IsPrivate,
+		&i.ChirpCount,
+		&i.FollowerCount,
+		&i.FollowingCount,
+	)
+	return i, err
+}
+
+const getUsersTokensValidAfterSince = `-- name: GetUsersTokensValidAfterSince :many
+SELECT id, tokens_valid_after FROM users
+WHERE tokens_valid_after > $1
+`
+
+type GetUsersTokensValidAfterSinceRow struct {
+	ID               uuid.UUID
+	TokensValidAfter sql.NullTime
+}
+
+func (q *Queries) GetUsersTokensValidAfterSince(ctx context.Context, tokensValidAfter sql.NullTime) ([]GetUsersTokensValidAfterSinceRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersTokensValidAfterSince, tokensValidAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersTokensValidAfterSinceRow
+	for rows.Next() {
+		var i GetUsersTokensValidAfterSinceRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TokensValidAfter,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordMFAFailure = `-- name: RecordMFAFailure :exec
+UPDATE users
+SET mfa_failed_attempts = $2,
+    mfa_locked_until = $3
+WHERE id = $1
+`
+
+type RecordMFAFailureParams struct {
+	ID                uuid.UUID
+	MFAFailedAttempts int32
+	MFALockedUntil    sql.NullTime
+}
+
+func (q *Queries) RecordMFAFailure(ctx context.Context, arg RecordMFAFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordMFAFailure, arg.ID, arg.MFAFailedAttempts, arg.MFALockedUntil)
+	return err
+}
+
+const resetMFAFailures = `-- name: ResetMFAFailures :exec
+UPDATE users
+SET mfa_failed_attempts = 0,
+    mfa_locked_until = NULL
+WHERE id = $1
+`
+
+func (q *Queries) ResetMFAFailures(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, resetMFAFailures, id)
+	return err
+}
+
+const setUserTOTPLastStep = `-- name: SetUserTOTPLastStep :exec
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1
+`
+
+type SetUserTOTPLastStepParams struct {
+	ID           uuid.UUID
+	TOTPLastStep int64
+}
+
+func (q *Queries) SetUserTOTPLastStep(ctx context.Context, arg SetUserTOTPLastStepParams) error {
+	_, err := q.db.ExecContext(ctx, setUserTOTPLastStep, arg.ID, arg.TOTPLastStep)
+	return err
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :execrows
+UPDATE users
+SET totp_secret = $2,
+    updated_at = NOW()
+WHERE id = $1
+    AND NOT totp_enabled
+`
+
+type SetUserTOTPSecretParams struct {
+	ID         uuid.UUID
+	TOTPSecret sql.NullString
+}
+
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserTOTPSecret, arg.ID, arg.TOTPSecret)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserTokensValidAfter = `-- name: SetUserTokensValidAfter :exec
+UPDATE users
+SET tokens_valid_after = $2,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserTokensValidAfterParams struct {
+	ID               uuid.UUID
+	TokensValidAfter sql.NullTime
+}
+
+func (q *Queries) SetUserTokensValidAfter(ctx context.Context, arg SetUserTokensValidAfterParams) error {
+	_, err := q.db.ExecContext(ctx, setUserTokensValidAfter, arg.ID, arg.TokensValidAfter)
+	return err
+}
+
+const suspendUser = `-- name: SuspendUser :exec
 UPDATE users
-SET email = $2,
-    hashed_password = $3,
+SET suspended_at = NOW(),
+    tokens_valid_after = $2,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+`
+
+type SuspendUserParams struct {
+	ID               uuid.UUID
+	TokensValidAfter sql.NullTime
+}
+
+func (q *Queries) SuspendUser(ctx context.Context, arg SuspendUserParams) error {
+	_, err := q.db.ExecContext(ctx, suspendUser, arg.ID, arg.TokensValidAfter)
+	return err
+}
+
+const unsuspendUser = `-- name: UnsuspendUser :execrows
+UPDATE users
+SET suspended_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) UnsuspendUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unsuspendUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE users
+SET email = COALESCE($1, email),
+    hashed_password = COALESCE($2, hashed_password),
+    handle = COALESCE($3, handle),
+    display_name = COALESCE($4, display_name),
+    bio = COALESCE($5, bio),
+    avatar_url = COALESCE($6, avatar_url),
+    is_private = COALESCE($7, is_private),
+    updated_at = NOW()
+WHERE id = $8
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, is_private, tokens_valid_after, totp_secret, totp_enabled, totp_last_step, mfa_failed_attempts, mfa_locked_until, suspended_at
 `
 
 type UpdateUserParams struct {
+	Email          sql.NullString
+	HashedPassword sql.NullString
+	Handle         sql.NullString
+	DisplayName    sql.NullString
+	Bio            sql.NullString
+	AvatarURL      sql.NullString
+	IsPrivate      sql.NullBool
 	ID             uuid.UUID
-	Email          string
-	HashedPassword string
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUser, arg.ID, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, updateUser,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarURL,
+		arg.IsPrivate,
+		arg.ID,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -80,6 +430,18 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarURL,
+		&i.IsPrivate,
+		&i.TokensValidAfter,
+		&i.TOTPSecret,
+		&i.TOTPEnabled,
+		&i.TOTPLastStep,
+		&i.MFAFailedAttempts,
+		&i.MFALockedUntil,
+		&i.SuspendedAt,
 	)
 	return i, err
 }
@@ -88,7 +450,7 @@ const upgradeUserToRed = `-- name: UpgradeUserToRed :one
 UPDATE users
 SET is_chirpy_red = TRUE
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, is_private, tokens_valid_after, totp_secret, totp_enabled, totp_last_step, mfa_failed_attempts, mfa_locked_until, suspended_at
 `
 
 func (q *Queries) UpgradeUserToRed(ctx context.Context, id uuid.UUID) (User, error) {
@@ -101,6 +463,18 @@ func (q *Queries) UpgradeUserToRed(ctx context.Context, id uuid.UUID) (User, err
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarURL,
+		&i.IsPrivate,
+		&i.TokensValidAfter,
+		&i.TOTPSecret,
+		&i.TOTPEnabled,
+		&i.TOTPLastStep,
+		&i.MFAFailedAttempts,
+		&i.MFALockedUntil,
+		&i.SuspendedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+var errAccessTokenRevoked = errors.New("access token has been revoked")
+
+// parseAccessToken validates an access token and returns its claims. On
+// top of the checks in auth.ParseJWT it rejects tokens on the revocation
+// list and tokens issued before the user last revoked all of their
+// sessions, both from the in-memory cache.
+func (cfg *ApiConfig) parseAccessToken(token string) (auth.AccessClaims, error) {
+	claims, err := auth.ParseJWT(token, cfg.JWTKeys)
+	if err != nil {
+		return auth.AccessClaims{}, err
+	}
+	if cfg.Revocations.IsRevoked(claims.ID) {
+		return auth.AccessClaims{}, errAccessTokenRevoked
+	}
+	// Both times are in milliseconds.
+	if cfg.Revocations.IsIssuedBeforeValidAfter(claims.UserID, claims.IssuedAt) {
+		return auth.AccessClaims{}, errAccessTokenRevoked
+	}
+	return claims, nil
+}
+
+// validateAccessToken is parseAccessToken for callers that only need the
+// user ID.
+func (cfg *ApiConfig) validateAccessToken(token string) (uuid.UUID, error) {
+	claims, err := cfg.parseAccessToken(token)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return claims.UserID, nil
+}
+
+// revokeUserAccessTokens signs userID out of every session except
+// keepFamily, which may be invalid to sign out of all of them, and puts
+// their unexpired access tokens on the revocation list. It is meant to run
+// in the transaction that changes the user's credentials or suspends them;
+// pass what it returns to addRevocations once it commits.
+func revokeUserAccessTokens(q *database.Queries, userID uuid.UUID, keepFamily uuid.NullUUID) ([]database.RevokedAccessToken, error) {
+	revoked, err := q.RevokeUserAccessTokens(context.Background(), database.RevokeUserAccessTokensParams{
+		UserID:       userID,
+		KeepFamilyID: keepFamily,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = q.RevokeUserSessionsExcept(context.Background(), database.RevokeUserSessionsExceptParams{
+		UserID:       userID,
+		KeepFamilyID: keepFamily,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revoked, nil
+}
+
+// addRevocations puts committed revocations on this instance's list right
+// away instead of at the next periodic refresh. Other instances pick them
+// up from the database.
+func (cfg *ApiConfig) addRevocations(revoked []database.RevokedAccessToken) {
+	for _, token := range revoked {
+		cfg.Revocations.Add(token.JTI, token.ExpiresAt)
+	}
+}
+
+// HandleJWKS serves the public keys access tokens are signed with, so other
+// services can verify them without being able to mint them.
+func (cfg *ApiConfig) HandleJWKS(w http.ResponseWriter, r *http.Request) {
+	// Verifiers may cache the set briefly; a new key is published well
+	// before anything is signed with it.
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	res.RespondWithJSON(w, http.StatusOK, cfg.JWTKeys.JWKS())
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+// relationRequest authenticates a block or mute request and resolves the
+// user it targets. It writes the error response itself and reports whether
+// the handler should go on.
+func (cfg *ApiConfig) relationRequest(w http.ResponseWriter, r *http.Request) (userID, targetID uuid.UUID, ok bool) {
+	targetID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, err = cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if targetID == userID {
+		res.RespondWithError(w, http.StatusBadRequest, "You can't do that to yourself", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	_, err = cfg.DB.GetUserByID(context.Background(), targetID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, targetID, true
+}
+
+// isBlocked reports whether either user has blocked the other.
+func isBlocked(q *database.Queries, userA, userB uuid.UUID) (bool, error) {
+	return q.IsBlockedBetween(context.Background(), database.IsBlockedBetweenParams{
+		UserA: userA,
+		UserB: userB,
+	})
+}
+
+// HandleBlockUser blocks a user. Any follow relationship or pending follow
+// request between the two is removed in the same transaction, so neither
+// shows up in the other's timeline afterwards.
+func (cfg *ApiConfig) HandleBlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.relationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error blocking user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	// Serialised with HandleFollowUser, which checks for blocks under the
+	// same lock.
+	err = qtx.LockUserPair(context.Background(), database.LockUserPairParams{
+		UserA: userID,
+		UserB: targetID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error blocking user", err)
+		return
+	}
+	err = qtx.BlockUser(context.Background(), database.BlockUserParams{
+		BlockerID: userID,
+		BlockedID: targetID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error blocking user", err)
+		return
+	}
+	err = qtx.DeleteFollowsBetween(context.Background(), database.DeleteFollowsBetweenParams{
+		UserA: userID,
+		UserB: targetID,
+	})
+	if err == nil {
+		err = qtx.DeleteFollowRequestsBetween(context.Background(), database.DeleteFollowRequestsBetweenParams{
+			UserA: userID,
+			UserB: targetID,
+		})
+	}
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error blocking user", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error blocking user", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// HandleUnblockUser lifts a block. Follows removed by the block are not
+// restored.
+func (cfg *ApiConfig) HandleUnblockUser(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.relationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.DB.UnblockUser(context.Background(), database.UnblockUserParams{
+		BlockerID: userID,
+		BlockedID: targetID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error unblocking user", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// HandleMuteUser hides a user's chirps and rechirps from the muter's
+// timeline and their actions from the muter's notifications. Unlike a
+// block, the muted user is not told and can still interact.
+func (cfg *ApiConfig) HandleMuteUser(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.relationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.DB.MuteUser(context.Background(), database.MuteUserParams{
+		MuterID: userID,
+		MutedID: targetID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error muting user", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *ApiConfig) HandleUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.relationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.DB.UnmuteUser(context.Background(), database.UnmuteUserParams{
+		MuterID: userID,
+		MutedID: targetID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error unmuting user", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
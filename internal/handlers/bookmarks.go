@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+// HandleBookmarkChirp saves a chirp to the authenticated user's bookmarks.
+// Bookmarks are private: nobody else can list them or see that they exist.
+func (cfg *ApiConfig) HandleBookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	chirp, err := cfg.DB.GetVisibleChirpByID(context.Background(), database.GetVisibleChirpByIDParams{
+		ID:       parsedChirpID,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil || chirp.DeletedAt.Valid {
+		res.RespondWithError(w, http.StatusNotFound, "Error fetching Chirp", err)
+		return
+	}
+
+	err = cfg.DB.CreateBookmark(context.Background(), database.CreateBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error bookmarking Chirp", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *ApiConfig) HandleUnbookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	err = cfg.DB.DeleteBookmark(context.Background(), database.DeleteBookmarkParams{
+		UserID:  userID,
+		ChirpID: parsedChirpID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error removing bookmark", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// HandleGetBookmarks lists the authenticated user's bookmarks, most recently
+// saved first. Chirps the user can no longer see are left out.
+func (cfg *ApiConfig) HandleGetBookmarks(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.DB.GetBookmarks(context.Background(), database.GetBookmarksParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching bookmarks", err)
+		return
+	}
+
+	rows, nextCursor := paginate(rows, page, func(row database.GetBookmarksRow) pageCursor {
+		return pageCursor{CreatedAt: row.BookmarkedAt, ID: row.Chirp.ID}
+	})
+	chirps := []Chirp{}
+	for _, row := range rows {
+		chirps = append(chirps, toChirp(row.Chirp))
+	}
+	err = cfg.decorateChirps(uuid.NullUUID{UUID: userID, Valid: true}, chirpPtrs(chirps)...)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching bookmarks", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusOK, chirpPage{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
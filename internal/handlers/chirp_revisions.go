@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+type ChirpRevision struct {
+	ID         uuid.UUID `json:"id"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// HandleGetChirpRevisions lists the previous bodies of a chirp, newest first.
+func (cfg *ApiConfig) HandleGetChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	// The history of a chirp is as private as the chirp itself.
+	chirp, err := cfg.DB.GetVisibleChirpByID(context.Background(), database.GetVisibleChirpByIDParams{
+		ID:       parsedChirpID,
+		ViewerID: cfg.viewerID(r),
+	})
+	if err != nil || chirp.DeletedAt.Valid {
+		res.RespondWithError(w, http.StatusNotFound, "Error fetching Chirp", err)
+		return
+	}
+
+	dbRevisions, err := cfg.DB.GetChirpRevisions(context.Background(), chirp.ID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching revisions", err)
+		return
+	}
+
+	revisions := []ChirpRevision{}
+	for _, dbRevision := range dbRevisions {
+		revisions = append(revisions, ChirpRevision{
+			ID:         dbRevision.ID,
+			Body:       dbRevision.Body,
+			CreatedAt:  dbRevision.CreatedAt,
+			ReplacedAt: dbRevision.ReplacedAt,
+		})
+	}
+	res.RespondWithJSON(w, http.StatusOK, revisions)
+}
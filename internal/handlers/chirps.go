@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -11,20 +13,102 @@ import (
 	"github.com/google/uuid"
 	"github.com/sebmaz93/gocial_server/internal/auth"
 	"github.com/sebmaz93/gocial_server/internal/database"
+	"github.com/sebmaz93/gocial_server/internal/notifications"
 	res "github.com/sebmaz93/gocial_server/internal/response"
+	"github.com/sebmaz93/gocial_server/internal/stream"
 )
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Body        string     `json:"body"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Edited      bool       `json:"edited"`
+	InReplyToID *uuid.UUID `json:"in_reply_to_id"`
+	ReplyCount  int32      `json:"reply_count"`
+	LikeCount   int32      `json:"like_count"`
+	LikedByMe   bool       `json:"liked_by_me"`
+	// BookmarkedByMe is only ever true for the owner of the bookmark.
+	BookmarkedByMe bool       `json:"bookmarked_by_me"`
+	QuoteOfID      *uuid.UUID `json:"quote_of_id"`
+	Visibility     string     `json:"visibility"`
+	Pinned         bool       `json:"pinned"`
+	Mentions       []Mention  `json:"mentions"`
+	// QuotedChirp is the chirp a quote refers to. It is left out, and
+	// QuoteUnavailable set, once the original has been deleted or when the
+	// viewer may not see it.
+	QuotedChirp      *Chirp `json:"quoted_chirp,omitempty"`
+	QuoteUnavailable bool   `json:"quote_unavailable,omitempty"`
+	// RechirpOf is set when this item is a rechirp. The item's own ID,
+	// UserID and CreatedAt then describe the rechirp itself.
+	RechirpOf *Chirp `json:"rechirp_of,omitempty"`
+	// Deleted marks a tombstone: a deleted chirp kept so its replies stay
+	// attached to the thread.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// chirpPage is the envelope for paginated chirp listings.
+type chirpPage struct {
+	Chirps     []Chirp `json:"chirps"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+func toChirp(dbChirp database.Chirp) Chirp {
+	chirp := Chirp{
+		ID:         dbChirp.ID,
+		CreatedAt:  dbChirp.CreatedAt,
+		UpdatedAt:  dbChirp.UpdatedAt,
+		Body:       dbChirp.Body,
+		UserID:     dbChirp.UserID,
+		Edited:     dbChirp.UpdatedAt.After(dbChirp.CreatedAt),
+		ReplyCount: dbChirp.ReplyCount,
+		LikeCount:  dbChirp.LikeCount,
+		Visibility: dbChirp.Visibility,
+		Pinned:     dbChirp.PinnedAt.Valid,
+		Deleted:    dbChirp.DeletedAt.Valid,
+	}
+	if dbChirp.InReplyToID.Valid {
+		chirp.InReplyToID = &dbChirp.InReplyToID.UUID
+	}
+	if dbChirp.QuoteOfID.Valid {
+		chirp.QuoteOfID = &dbChirp.QuoteOfID.UUID
+	}
+	return chirp
+}
+
+// feedItem converts a row of an author or timeline feed, where rechirps are
+// listed alongside authored chirps.
+func feedItem(dbChirp database.Chirp, itemID uuid.UUID, itemCreatedAt time.Time, rechirpedBy uuid.NullUUID) Chirp {
+	if !rechirpedBy.Valid {
+		return toChirp(dbChirp)
+	}
+	original := toChirp(dbChirp)
+	return Chirp{
+		ID:        itemID,
+		CreatedAt: itemCreatedAt,
+		UpdatedAt: itemCreatedAt,
+		UserID:    rechirpedBy.UUID,
+		RechirpOf: &original,
+	}
+}
+
+func toChirps(dbChirps []database.Chirp) []Chirp {
+	chirps := []Chirp{}
+	for _, dbChirp := range dbChirps {
+		chirps = append(chirps, toChirp(dbChirp))
+	}
+	return chirps
 }
 
 func (cfg *ApiConfig) HandleCreateChirp(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
-		Body string `json:"body"`
+		Body        string     `json:"body"`
+		InReplyToID *uuid.UUID `json:"in_reply_to_id"`
+		QuoteOfID   *uuid.UUID `json:"quote_of_id"`
+		Visibility  string     `json:"visibility"`
+		// PublishAt schedules the chirp instead of posting it right away.
+		PublishAt *time.Time `json:"publish_at"`
 	}
 
 	token, err := auth.GetBearerToken(r.Header)
@@ -33,7 +117,7 @@ func (cfg *ApiConfig) HandleCreateChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	userId, err := auth.ValidateJWT(token, cfg.JWTSecret)
+	userId, err := cfg.validateAccessToken(token)
 	if err != nil {
 		res.RespondWithError(w, http.StatusUnauthorized, "error validating token", err)
 		return
@@ -53,21 +137,234 @@ func (cfg *ApiConfig) HandleCreateChirp(w http.ResponseWriter, r *http.Request)
 		res.RespondWithError(w, http.StatusBadRequest, "error sanitizng chirp", err)
 		return
 	}
+	visibility, err := parseVisibility(requestBody.Visibility)
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
 
-	chirp, err := cfg.DB.CreateChirp(context.Background(), database.CreateChirpParams{
-		Body:   cleaned,
-		UserID: userId,
-	})
+	if requestBody.PublishAt != nil {
+		cfg.scheduleChirp(w, database.CreateScheduledChirpParams{
+			UserID:      userId,
+			Body:        cleaned,
+			InReplyToID: toNullUUID(requestBody.InReplyToID),
+			QuoteOfID:   toNullUUID(requestBody.QuoteOfID),
+			Visibility:  visibility,
+			PublishAt:   *requestBody.PublishAt,
+		})
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
 	if err != nil {
 		res.RespondWithError(w, http.StatusInternalServerError, "Error creating chirp", err)
+		return
 	}
-	res.RespondWithJSON(w, http.StatusCreated, Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	targets, err := resolveChirpTargets(qtx, userId, toNullUUID(requestBody.InReplyToID), toNullUUID(requestBody.QuoteOfID))
+	if err != nil {
+		respondWithChirpTargetError(w, err)
+		return
+	}
+
+	chirp, mentionedIDs, err := insertChirp(qtx, userId, cleaned, visibility, targets)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating chirp", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating chirp", err)
+		return
+	}
+
+	response := toChirp(chirp)
+	err = cfg.decorateChirps(uuid.NullUUID{UUID: userId, Valid: true}, &response)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating chirp", err)
+		return
+	}
+	cfg.announceChirp(response, targets, mentionedIDs)
+	res.RespondWithJSON(w, http.StatusCreated, response)
+}
+
+// Reasons a chirp can't reply to or quote another one.
+var (
+	errReplyParentNotFound = errors.New("Chirp being replied to not found")
+	errReplyForbidden      = errors.New("You can't reply to this chirp")
+	errQuotedNotFound      = errors.New("Quoted chirp not found")
+	errQuoteForbidden      = errors.New("This chirp can't be quoted")
+)
+
+// chirpTargets are the chirps a new chirp replies to and quotes, once the
+// author has been checked against them.
+type chirpTargets struct {
+	InReplyToID    uuid.NullUUID
+	ParentAuthorID uuid.UUID
+	QuoteOfID      uuid.NullUUID
+}
+
+// resolveChirpTargets checks that userID may reply to inReplyToID and quote
+// quoteOfID, either of which may be null. Refusals are reported with the
+// errors above; any other error comes from the database.
+func resolveChirpTargets(q *database.Queries, userID uuid.UUID, inReplyToID, quoteOfID uuid.NullUUID) (chirpTargets, error) {
+	targets := chirpTargets{}
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+
+	if inReplyToID.Valid {
+		parent, err := q.GetChirpByIDForUpdate(context.Background(), inReplyToID.UUID)
+		if err != nil || parent.DeletedAt.Valid {
+			return chirpTargets{}, errReplyParentNotFound
+		}
+		blocked, err := isBlocked(q, userID, parent.UserID)
+		if err != nil {
+			return chirpTargets{}, err
+		}
+		if blocked {
+			return chirpTargets{}, errReplyForbidden
+		}
+		_, err = q.GetVisibleChirpByID(context.Background(), database.GetVisibleChirpByIDParams{
+			ID:       parent.ID,
+			ViewerID: viewerID,
+		})
+		if err != nil {
+			return chirpTargets{}, errReplyParentNotFound
+		}
+		targets.InReplyToID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		targets.ParentAuthorID = parent.UserID
+	}
+
+	if quoteOfID.Valid {
+		quoted, err := q.GetVisibleChirpByID(context.Background(), database.GetVisibleChirpByIDParams{
+			ID:       quoteOfID.UUID,
+			ViewerID: viewerID,
+		})
+		if err != nil || quoted.DeletedAt.Valid {
+			return chirpTargets{}, errQuotedNotFound
+		}
+		// The quoted chirp is embedded for everyone who sees the quote, so
+		// only chirps visible to anyone can be quoted.
+		quotedAuthor, err := q.GetUserByID(context.Background(), quoted.UserID)
+		if err != nil {
+			return chirpTargets{}, err
+		}
+		if quoted.Visibility != visibilityPublic || quotedAuthor.IsPrivate {
+			return chirpTargets{}, errQuoteForbidden
+		}
+		targets.QuoteOfID = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+	}
+
+	return targets, nil
+}
+
+// isChirpTargetRefusal reports whether err is one of the refusals returned
+// by resolveChirpTargets.
+func isChirpTargetRefusal(err error) bool {
+	return errors.Is(err, errReplyParentNotFound) || errors.Is(err, errReplyForbidden) ||
+		errors.Is(err, errQuotedNotFound) || errors.Is(err, errQuoteForbidden)
+}
+
+func respondWithChirpTargetError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errReplyParentNotFound), errors.Is(err, errQuotedNotFound):
+		res.RespondWithError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, errReplyForbidden), errors.Is(err, errQuoteForbidden):
+		res.RespondWithError(w, http.StatusForbidden, err.Error(), nil)
+	default:
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating chirp", err)
+	}
+}
+
+// insertChirp stores a new chirp with its tags and mentions and returns the
+// IDs of the users it mentions. q should be bound to the transaction that
+// resolved targets.
+func insertChirp(q *database.Queries, userID uuid.UUID, body, visibility string, targets chirpTargets) (database.Chirp, []uuid.UUID, error) {
+	if targets.InReplyToID.Valid {
+		err := q.IncrementReplyCount(context.Background(), targets.InReplyToID.UUID)
+		if err != nil {
+			return database.Chirp{}, nil, err
+		}
+	}
+
+	chirp, err := q.CreateChirp(context.Background(), database.CreateChirpParams{
+		Body:        body,
+		UserID:      userID,
+		InReplyToID: targets.InReplyToID,
+		QuoteOfID:   targets.QuoteOfID,
+		Visibility:  visibility,
 	})
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	err = setChirpTags(q, chirp)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+	mentionedIDs, err := setChirpMentions(q, chirp)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+	return chirp, mentionedIDs, nil
+}
+
+// announceChirp notifies the users a newly created chirp replies to or
+// mentions, if they may see it, and pushes it to live streams. chirp is
+// the decorated chirp as sent to clients.
+func (cfg *ApiConfig) announceChirp(chirp Chirp, targets chirpTargets, mentionedIDs []uuid.UUID) {
+	recipientIDs := make([]uuid.UUID, 0, len(mentionedIDs)+1)
+	recipientIDs = append(recipientIDs, mentionedIDs...)
+	if targets.InReplyToID.Valid {
+		recipientIDs = append(recipientIDs, targets.ParentAuthorID)
+	}
+	if len(recipientIDs) > 0 {
+		viewerIDs, err := cfg.DB.GetChirpViewers(context.Background(), database.GetChirpViewersParams{
+			ChirpID: chirp.ID,
+			UserIds: recipientIDs,
+		})
+		// Notifications are best effort: rather than risk notifying someone
+		// who can't open the chirp, nobody is notified.
+		if err != nil {
+			log.Printf("Error checking who may see chirp %s: %s", chirp.ID, err)
+		}
+		canSee := make(map[uuid.UUID]bool, len(viewerIDs))
+		for _, id := range viewerIDs {
+			canSee[id] = true
+		}
+
+		if targets.InReplyToID.Valid && canSee[targets.ParentAuthorID] {
+			cfg.Notifier.Notify(targets.ParentAuthorID, notifications.TypeReply, chirp.UserID, chirp.ID)
+		}
+		for _, mentionedID := range mentionedIDs {
+			if canSee[mentionedID] {
+				cfg.Notifier.Notify(mentionedID, notifications.TypeMention, chirp.UserID, chirp.ID)
+			}
+		}
+	}
+	if cfg.isStreamable(chirp.UserID, chirp.Visibility) {
+		cfg.publishChirpEvent(stream.EventChirpCreated, chirp.UserID, chirp)
+	}
+}
+
+// isStreamable reports whether events about a chirp by authorID may be
+// pushed to live streams. Streams are not filtered per viewer, so only
+// chirps anyone may see are pushed to them.
+func (cfg *ApiConfig) isStreamable(authorID uuid.UUID, visibility string) bool {
+	if visibility != visibilityPublic {
+		return false
+	}
+	author, err := cfg.DB.GetUserByID(context.Background(), authorID)
+	return err == nil && !author.IsPrivate
+}
+
+func toNullUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
 }
 
 func validateChirp(body string) (string, error) {
@@ -104,41 +401,108 @@ func (cfg *ApiConfig) HandleGetAllChirps(w http.ResponseWriter, r *http.Request)
 	if sortQuery == "desc" {
 		sortOrder = "desc"
 	}
-	var dbChirps []database.Chirp
-	var err error
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	var chirps []Chirp
+	var nextCursor string
 	if authorID != "" {
 		parsedID, err := uuid.Parse(authorID)
 		if err != nil {
 			res.RespondWithError(w, http.StatusInternalServerError, "malformed user id", err)
 			return
 		}
-		dbChirps, err = cfg.DB.GetChirpsByAuthorID(context.Background(), database.GetChirpsByAuthorIDParams{
-			UserID:  parsedID,
-			Column2: sortOrder,
-		})
-		if err != nil {
-			res.RespondWithError(w, http.StatusInternalServerError, "Error fetching chirps", err)
-			return
+		// Author listings are feeds: they include the author's rechirps.
+		// Pinned chirps lead the first page and are left out of the rest.
+		chirps = []Chirp{}
+		if page.Cursor == nil {
+			pinned, err := cfg.DB.GetPinnedChirps(context.Background(), database.GetPinnedChirpsParams{
+				UserID:   parsedID,
+				ViewerID: viewerID,
+			})
+			if err != nil {
+				res.RespondWithError(w, http.StatusInternalServerError, "Error fetching chirps", err)
+				return
+			}
+			chirps = append(chirps, toChirps(pinned)...)
+		}
+		if sortOrder == "desc" {
+			rows, err := cfg.DB.GetChirpsByAuthorIDDesc(context.Background(), database.GetChirpsByAuthorIDDescParams{
+				UserID:          parsedID,
+				CursorCreatedAt: page.cursorCreatedAt(),
+				CursorID:        page.cursorID(),
+				ViewerID:        viewerID,
+				PageLimit:       page.fetchLimit(),
+			})
+			if err != nil {
+				res.RespondWithError(w, http.StatusInternalServerError, "Error fetching chirps", err)
+				return
+			}
+			rows, nextCursor = paginate(rows, page, func(row database.GetChirpsByAuthorIDDescRow) pageCursor {
+				return pageCursor{CreatedAt: row.ItemCreatedAt, ID: row.ItemID}
+			})
+			for _, row := range rows {
+				chirps = append(chirps, feedItem(row.Chirp, row.ItemID, row.ItemCreatedAt, row.RechirpedBy))
+			}
+		} else {
+			rows, err := cfg.DB.GetChirpsByAuthorID(context.Background(), database.GetChirpsByAuthorIDParams{
+				UserID:          parsedID,
+				CursorCreatedAt: page.cursorCreatedAt(),
+				CursorID:        page.cursorID(),
+				ViewerID:        viewerID,
+				PageLimit:       page.fetchLimit(),
+			})
+			if err != nil {
+				res.RespondWithError(w, http.StatusInternalServerError, "Error fetching chirps", err)
+				return
+			}
+			rows, nextCursor = paginate(rows, page, func(row database.GetChirpsByAuthorIDRow) pageCursor {
+				return pageCursor{CreatedAt: row.ItemCreatedAt, ID: row.ItemID}
+			})
+			for _, row := range rows {
+				chirps = append(chirps, feedItem(row.Chirp, row.ItemID, row.ItemCreatedAt, row.RechirpedBy))
+			}
 		}
 	} else {
-		dbChirps, err = cfg.DB.GetAllChirps(context.Background(), sortOrder)
+		var dbChirps []database.Chirp
+		if sortOrder == "desc" {
+			dbChirps, err = cfg.DB.GetAllChirpsDesc(context.Background(), database.GetAllChirpsDescParams{
+				CursorCreatedAt: page.cursorCreatedAt(),
+				CursorID:        page.cursorID(),
+				ViewerID:        viewerID,
+				PageLimit:       page.fetchLimit(),
+			})
+		} else {
+			dbChirps, err = cfg.DB.GetAllChirps(context.Background(), database.GetAllChirpsParams{
+				CursorCreatedAt: page.cursorCreatedAt(),
+				CursorID:        page.cursorID(),
+				ViewerID:        viewerID,
+				PageLimit:       page.fetchLimit(),
+			})
+		}
 		if err != nil {
 			res.RespondWithError(w, http.StatusInternalServerError, "Error fetching chirps", err)
 			return
 		}
+		dbChirps, nextCursor = paginate(dbChirps, page, func(c database.Chirp) pageCursor {
+			return pageCursor{CreatedAt: c.CreatedAt, ID: c.ID}
+		})
+		chirps = toChirps(dbChirps)
 	}
 
-	chirps := []Chirp{}
-	for _, dbChirp := range dbChirps {
-		chirps = append(chirps, Chirp{
-			ID:        dbChirp.ID,
-			CreatedAt: dbChirp.CreatedAt,
-			UpdatedAt: dbChirp.UpdatedAt,
-			UserID:    dbChirp.UserID,
-			Body:      dbChirp.Body,
-		})
+	err = cfg.decorateChirps(viewerID, chirpPtrs(chirps)...)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching chirps", err)
+		return
 	}
-	res.RespondWithJSON(w, http.StatusOK, chirps)
+	res.RespondWithJSON(w, http.StatusOK, chirpPage{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
 }
 
 func (cfg *ApiConfig) HandleGetChirpByID(w http.ResponseWriter, r *http.Request) {
@@ -154,19 +518,25 @@ func (cfg *ApiConfig) HandleGetChirpByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	chirp, err := cfg.DB.GetChirpByID(context.Background(), parsedChirpID)
-	if err != nil {
+	viewerID := cfg.viewerID(r)
+	// Chirps the viewer may not see are reported as missing, not forbidden,
+	// so their existence is not revealed.
+	chirp, err := cfg.DB.GetVisibleChirpByID(context.Background(), database.GetVisibleChirpByIDParams{
+		ID:       parsedChirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil || chirp.DeletedAt.Valid {
 		res.RespondWithError(w, http.StatusNotFound, "Error fetching Chirp", err)
 		return
 	}
 
-	res.RespondWithJSON(w, http.StatusOK, Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	response := toChirp(chirp)
+	err = cfg.decorateChirps(viewerID, &response)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching Chirp", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusOK, response)
 }
 
 func (cfg *ApiConfig) HandleDeleteChirpByID(w http.ResponseWriter, r *http.Request) {
@@ -188,14 +558,22 @@ func (cfg *ApiConfig) HandleDeleteChirpByID(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.JWTSecret)
+	userID, err := cfg.validateAccessToken(token)
 	if err != nil {
 		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
 		return
 	}
 
-	chirp, err := cfg.DB.GetChirpByID(context.Background(), parsedChirpID)
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
 	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error deleting Chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIDForUpdate(context.Background(), parsedChirpID)
+	if err != nil || chirp.DeletedAt.Valid {
 		res.RespondWithError(w, http.StatusNotFound, "Error fetching Chirp", err)
 		return
 	}
@@ -205,11 +583,180 @@ func (cfg *ApiConfig) HandleDeleteChirpByID(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err = cfg.DB.DeleteChirpById(context.Background(), chirp.ID)
+	// A chirp with replies becomes a tombstone so the thread below it
+	// stays reachable; otherwise it is removed outright. A tombstone is
+	// still a reply, so its parent keeps counting it.
+	if chirp.ReplyCount > 0 {
+		err = qtx.TombstoneChirp(context.Background(), chirp.ID)
+		if err == nil {
+			err = qtx.DeleteRechirpsOfChirp(context.Background(), chirp.ID)
+		}
+		if err == nil {
+			err = setChirpTags(qtx, database.Chirp{ID: chirp.ID})
+		}
+		if err == nil {
+			err = qtx.DeleteChirpMentions(context.Background(), chirp.ID)
+		}
+	} else {
+		err = qtx.DeleteChirpById(context.Background(), chirp.ID)
+		if err == nil {
+			err = detachReply(qtx, chirp.InReplyToID)
+		}
+	}
 	if err != nil {
 		res.RespondWithError(w, http.StatusInternalServerError, "Error deleting Chirp", err)
 		return
 	}
 
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error deleting Chirp", err)
+		return
+	}
+
+	type deletedChirp struct {
+		ID uuid.UUID `json:"id"`
+	}
+	if cfg.isStreamable(chirp.UserID, chirp.Visibility) {
+		cfg.publishChirpEvent(stream.EventChirpDeleted, chirp.UserID, deletedChirp{ID: chirp.ID})
+	}
+
 	res.RespondWithJSON(w, http.StatusNoContent, nil)
 }
+
+// detachReply updates the parent of a reply that was removed. A parent
+// that is a tombstone and has no replies left is removed as well, and so
+// on up the thread.
+func detachReply(q *database.Queries, parentID uuid.NullUUID) error {
+	for parentID.Valid {
+		err := q.DecrementReplyCount(context.Background(), parentID.UUID)
+		if err != nil {
+			return err
+		}
+		parent, err := q.GetChirpByIDForUpdate(context.Background(), parentID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !parent.DeletedAt.Valid || parent.ReplyCount > 0 {
+			return nil
+		}
+		err = q.DeleteChirpById(context.Background(), parent.ID)
+		if err != nil {
+			return err
+		}
+		parentID = parent.InReplyToID
+	}
+	return nil
+}
+
+func (cfg *ApiConfig) HandleUpdateChirp(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Body string `json:"body"`
+	}
+
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	requestBody := reqBody{}
+	defer r.Body.Close()
+	err = decoder.Decode(&requestBody)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	cleaned, err := validateChirp(requestBody.Body)
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "error sanitizng chirp", err)
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating Chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIDForUpdate(context.Background(), parsedChirpID)
+	if err != nil || chirp.DeletedAt.Valid {
+		res.RespondWithError(w, http.StatusNotFound, "Error fetching Chirp", err)
+		return
+	}
+
+	if chirp.UserID != userID {
+		res.RespondWithError(w, http.StatusForbidden, "You can't edit this Chirp", nil)
+		return
+	}
+
+	if chirp.Body == cleaned {
+		cfg.respondWithEditedChirp(w, userID, chirp)
+		return
+	}
+
+	err = qtx.CreateChirpRevision(context.Background(), database.CreateChirpRevisionParams{
+		ChirpID:   chirp.ID,
+		Body:      chirp.Body,
+		CreatedAt: chirp.UpdatedAt,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating Chirp", err)
+		return
+	}
+
+	updated, err := qtx.UpdateChirpBody(context.Background(), database.UpdateChirpBodyParams{
+		ID:   chirp.ID,
+		Body: cleaned,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating Chirp", err)
+		return
+	}
+
+	err = setChirpTags(qtx, updated)
+	if err == nil {
+		_, err = setChirpMentions(qtx, updated)
+	}
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating Chirp", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating Chirp", err)
+		return
+	}
+
+	cfg.respondWithEditedChirp(w, userID, updated)
+}
+
+func (cfg *ApiConfig) respondWithEditedChirp(w http.ResponseWriter, userID uuid.UUID, dbChirp database.Chirp) {
+	response := toChirp(dbChirp)
+	err := cfg.decorateChirps(uuid.NullUUID{UUID: userID, Valid: true}, &response)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating Chirp", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusOK, response)
+}
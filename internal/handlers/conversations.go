@@ -0,0 +1,479 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+const (
+	maxConversationParticipants = 10
+	maxMessageLength            = 1000
+)
+
+type Participant struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	LastReadAt *time.Time `json:"last_read_at"`
+}
+
+type Conversation struct {
+	ID           uuid.UUID     `json:"id"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	CreatedBy    uuid.UUID     `json:"created_by"`
+	IsGroup      bool          `json:"is_group"`
+	Participants []Participant `json:"participants,omitempty"`
+	UnreadCount  int64         `json:"unread_count"`
+}
+
+type Message struct {
+	ID             uuid.UUID `json:"id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type conversationPage struct {
+	Conversations []Conversation `json:"conversations"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+type messagePage struct {
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+func toConversation(c database.Conversation) Conversation {
+	return Conversation{
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		CreatedBy: c.CreatedBy,
+		IsGroup:   c.IsGroup,
+	}
+}
+
+func toMessage(m database.Message) Message {
+	return Message{
+		ID:             m.ID,
+		ConversationID: m.ConversationID,
+		SenderID:       m.SenderID,
+		Body:           m.Body,
+		CreatedAt:      m.CreatedAt,
+	}
+}
+
+// conversationWithParticipants loads the participants of a conversation;
+// their last_read_at doubles as read receipts.
+func (cfg *ApiConfig) conversationWithParticipants(c database.Conversation) (Conversation, error) {
+	conversation := toConversation(c)
+	participants, err := cfg.DB.GetConversationParticipants(context.Background(), c.ID)
+	if err != nil {
+		return Conversation{}, err
+	}
+	conversation.Participants = []Participant{}
+	for _, p := range participants {
+		participant := Participant{
+			UserID:   p.UserID,
+			JoinedAt: p.JoinedAt,
+		}
+		if p.LastReadAt.Valid {
+			participant.LastReadAt = &p.LastReadAt.Time
+		}
+		conversation.Participants = append(conversation.Participants, participant)
+	}
+	return conversation, nil
+}
+
+// conversationParticipants returns the distinct participants of a new
+// conversation, excluding its creator.
+func conversationParticipants(creatorID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	others := []uuid.UUID{}
+	seen := map[uuid.UUID]struct{}{creatorID: {}}
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		others = append(others, id)
+	}
+
+	if len(others) == 0 {
+		return nil, errors.New("a conversation needs at least one other participant")
+	}
+	if len(others)+1 > maxConversationParticipants {
+		return nil, errors.New("too many participants")
+	}
+	return others, nil
+}
+
+// HandleCreateConversation starts a conversation between the authenticated
+// user and `participant_ids`. Starting a one-to-one conversation that
+// already exists returns the existing one.
+func (cfg *ApiConfig) HandleCreateConversation(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	type parameters struct {
+		ParticipantIDs []uuid.UUID `json:"participant_ids"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	err = decoder.Decode(&params)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	others, err := conversationParticipants(userID, params.ParticipantIDs)
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	found, err := cfg.DB.CountUsersByIDs(context.Background(), others)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating conversation", err)
+		return
+	}
+	if found != int64(len(others)) {
+		res.RespondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating conversation", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	blocked, err := qtx.IsBlockedWithAny(context.Background(), database.IsBlockedWithAnyParams{
+		UserID:   userID,
+		OtherIds: others,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating conversation", err)
+		return
+	}
+	if blocked {
+		res.RespondWithError(w, http.StatusForbidden, "You can't message this user", nil)
+		return
+	}
+
+	// Two users share one direct conversation. The pair is locked until the
+	// transaction ends, so concurrent requests can't both create one.
+	if len(others) == 1 {
+		err = qtx.LockUserPair(context.Background(), database.LockUserPairParams{
+			UserA: userID,
+			UserB: others[0],
+		})
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error creating conversation", err)
+			return
+		}
+		existing, err := qtx.GetDirectConversation(context.Background(), database.GetDirectConversationParams{
+			UserA: userID,
+			UserB: others[0],
+		})
+		if err == nil {
+			cfg.respondWithConversation(w, http.StatusOK, existing)
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error creating conversation", err)
+			return
+		}
+	}
+
+	conversation, err := qtx.CreateConversation(context.Background(), database.CreateConversationParams{
+		CreatedBy: userID,
+		IsGroup:   len(others) > 1,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating conversation", err)
+		return
+	}
+	err = qtx.AddConversationParticipants(context.Background(), database.AddConversationParticipantsParams{
+		ConversationID: conversation.ID,
+		UserIds:        append([]uuid.UUID{userID}, others...),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating conversation", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating conversation", err)
+		return
+	}
+	cfg.respondWithConversation(w, http.StatusCreated, conversation)
+}
+
+func (cfg *ApiConfig) respondWithConversation(w http.ResponseWriter, code int, c database.Conversation) {
+	conversation, err := cfg.conversationWithParticipants(c)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching conversation", err)
+		return
+	}
+	res.RespondWithJSON(w, code, conversation)
+}
+
+// HandleGetConversations lists the authenticated user's conversations, most
+// recently active first.
+func (cfg *ApiConfig) HandleGetConversations(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.DB.GetConversationsForUser(context.Background(), database.GetConversationsForUserParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching conversations", err)
+		return
+	}
+
+	rows, nextCursor := paginate(rows, page, func(row database.GetConversationsForUserRow) pageCursor {
+		return pageCursor{CreatedAt: row.Conversation.UpdatedAt, ID: row.Conversation.ID}
+	})
+	conversations := []Conversation{}
+	for _, row := range rows {
+		conversation := toConversation(row.Conversation)
+		conversation.UnreadCount = row.UnreadCount
+		conversations = append(conversations, conversation)
+	}
+	res.RespondWithJSON(w, http.StatusOK, conversationPage{
+		Conversations: conversations,
+		NextCursor:    nextCursor,
+	})
+}
+
+// conversationForRequest authenticates the request and loads the
+// conversation in its path. Conversations the user isn't part of are
+// reported as not found.
+func (cfg *ApiConfig) conversationForRequest(w http.ResponseWriter, r *http.Request) (database.Conversation, uuid.UUID, bool) {
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid conversation ID", err)
+		return database.Conversation{}, uuid.Nil, false
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return database.Conversation{}, uuid.Nil, false
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return database.Conversation{}, uuid.Nil, false
+	}
+
+	conversation, err := cfg.DB.GetConversationForUser(context.Background(), database.GetConversationForUserParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusNotFound, "Conversation not found", err)
+		return database.Conversation{}, uuid.Nil, false
+	}
+	return conversation, userID, true
+}
+
+func (cfg *ApiConfig) HandleGetConversation(w http.ResponseWriter, r *http.Request) {
+	conversation, _, ok := cfg.conversationForRequest(w, r)
+	if !ok {
+		return
+	}
+	cfg.respondWithConversation(w, http.StatusOK, conversation)
+}
+
+func (cfg *ApiConfig) HandleCreateMessage(w http.ResponseWriter, r *http.Request) {
+	conversation, userID, ok := cfg.conversationForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Body string `json:"body"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	err := decoder.Decode(&params)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	body := strings.TrimSpace(params.Body)
+	if body == "" {
+		res.RespondWithError(w, http.StatusBadRequest, "Message is empty", nil)
+		return
+	}
+	if utf8.RuneCountInString(body) > maxMessageLength {
+		res.RespondWithError(w, http.StatusBadRequest, "Message is too long", nil)
+		return
+	}
+
+	// A block closes an existing one-to-one conversation. Group
+	// conversations stay open; blocked members simply share a room.
+	if !conversation.IsGroup {
+		participants, err := cfg.DB.GetConversationParticipants(context.Background(), conversation.ID)
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error sending message", err)
+			return
+		}
+		var others []uuid.UUID
+		for _, p := range participants {
+			if p.UserID != userID {
+				others = append(others, p.UserID)
+			}
+		}
+		blocked, err := cfg.DB.IsBlockedWithAny(context.Background(), database.IsBlockedWithAnyParams{
+			UserID:   userID,
+			OtherIds: others,
+		})
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error sending message", err)
+			return
+		}
+		if blocked {
+			res.RespondWithError(w, http.StatusForbidden, "You can't message this user", nil)
+			return
+		}
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error sending message", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	message, err := qtx.CreateMessage(context.Background(), database.CreateMessageParams{
+		ConversationID: conversation.ID,
+		SenderID:       userID,
+		Body:           body,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error sending message", err)
+		return
+	}
+	err = qtx.TouchConversation(context.Background(), conversation.ID)
+	if err == nil {
+		// Senders have read everything up to their own message.
+		err = qtx.MarkConversationRead(context.Background(), database.MarkConversationReadParams{
+			ConversationID: conversation.ID,
+			UserID:         userID,
+		})
+	}
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error sending message", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error sending message", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusCreated, toMessage(message))
+}
+
+// HandleGetMessages pages through a conversation's history, newest first.
+func (cfg *ApiConfig) HandleGetMessages(w http.ResponseWriter, r *http.Request) {
+	conversation, _, ok := cfg.conversationForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbMessages, err := cfg.DB.GetMessages(context.Background(), database.GetMessagesParams{
+		ConversationID:  conversation.ID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching messages", err)
+		return
+	}
+
+	dbMessages, nextCursor := paginate(dbMessages, page, func(m database.Message) pageCursor {
+		return pageCursor{CreatedAt: m.CreatedAt, ID: m.ID}
+	})
+	messages := []Message{}
+	for _, m := range dbMessages {
+		messages = append(messages, toMessage(m))
+	}
+	res.RespondWithJSON(w, http.StatusOK, messagePage{
+		Messages:   messages,
+		NextCursor: nextCursor,
+	})
+}
+
+// HandleMarkConversationRead records that the authenticated user has read
+// the conversation up to now.
+func (cfg *ApiConfig) HandleMarkConversationRead(w http.ResponseWriter, r *http.Request) {
+	conversation, userID, ok := cfg.conversationForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.DB.MarkConversationRead(context.Background(), database.MarkConversationReadParams{
+		ConversationID: conversation.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating conversation", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
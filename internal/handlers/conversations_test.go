@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestConversationParticipants(t *testing.T) {
+	creator := uuid.New()
+	alice := uuid.New()
+	bob := uuid.New()
+
+	tooMany := []uuid.UUID{}
+	for range maxConversationParticipants {
+		tooMany = append(tooMany, uuid.New())
+	}
+
+	tests := []struct {
+		name    string
+		ids     []uuid.UUID
+		want    []uuid.UUID
+		wantErr bool
+	}{
+		{name: "Direct", ids: []uuid.UUID{alice}, want: []uuid.UUID{alice}},
+		{name: "Group", ids: []uuid.UUID{alice, bob}, want: []uuid.UUID{alice, bob}},
+		{name: "Duplicates and creator removed", ids: []uuid.UUID{alice, creator, alice}, want: []uuid.UUID{alice}},
+		{name: "Only the creator", ids: []uuid.UUID{creator}, wantErr: true},
+		{name: "Empty", ids: nil, wantErr: true},
+		{name: "Too many", ids: tooMany, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := conversationParticipants(creator, tt.ids)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("conversationParticipants() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("conversationParticipants() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+// Draft is an unpublished chirp kept on the server. Its body is only
+// checked with validateChirp when the draft is published.
+type Draft struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Body        string     `json:"body"`
+	InReplyToID *uuid.UUID `json:"in_reply_to_id"`
+	QuoteOfID   *uuid.UUID `json:"quote_of_id"`
+	Visibility  string     `json:"visibility"`
+}
+
+type draftPage struct {
+	Drafts     []Draft `json:"drafts"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+func toDraft(d database.Draft) Draft {
+	draft := Draft{
+		ID:         d.ID,
+		CreatedAt:  d.CreatedAt,
+		UpdatedAt:  d.UpdatedAt,
+		Body:       d.Body,
+		Visibility: d.Visibility,
+	}
+	if d.InReplyToID.Valid {
+		draft.InReplyToID = &d.InReplyToID.UUID
+	}
+	if d.QuoteOfID.Valid {
+		draft.QuoteOfID = &d.QuoteOfID.UUID
+	}
+	return draft
+}
+
+// draftForRequest authenticates the request and loads the draft in its
+// path. Drafts owned by someone else are reported as not found.
+func (cfg *ApiConfig) draftForRequest(w http.ResponseWriter, r *http.Request) (database.Draft, bool) {
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid draft ID", err)
+		return database.Draft{}, false
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return database.Draft{}, false
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return database.Draft{}, false
+	}
+
+	draft, err := cfg.DB.GetDraftForUser(context.Background(), database.GetDraftForUserParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return database.Draft{}, false
+	}
+	return draft, true
+}
+
+func (cfg *ApiConfig) HandleCreateDraft(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	type parameters struct {
+		Body        string     `json:"body"`
+		InReplyToID *uuid.UUID `json:"in_reply_to_id"`
+		QuoteOfID   *uuid.UUID `json:"quote_of_id"`
+		Visibility  string     `json:"visibility"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	err = decoder.Decode(&params)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	visibility, err := parseVisibility(params.Visibility)
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	draft, err := cfg.DB.CreateDraft(context.Background(), database.CreateDraftParams{
+		UserID:      userID,
+		Body:        params.Body,
+		InReplyToID: toNullUUID(params.InReplyToID),
+		QuoteOfID:   toNullUUID(params.QuoteOfID),
+		Visibility:  visibility,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating draft", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusCreated, toDraft(draft))
+}
+
+// HandleGetDrafts pages through the authenticated user's drafts, newest
+// first.
+func (cfg *ApiConfig) HandleGetDrafts(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbDrafts, err := cfg.DB.GetDraftsForUser(context.Background(), database.GetDraftsForUserParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching drafts", err)
+		return
+	}
+
+	dbDrafts, nextCursor := paginate(dbDrafts, page, func(d database.Draft) pageCursor {
+		return pageCursor{CreatedAt: d.CreatedAt, ID: d.ID}
+	})
+	drafts := []Draft{}
+	for _, d := range dbDrafts {
+		drafts = append(drafts, toDraft(d))
+	}
+	res.RespondWithJSON(w, http.StatusOK, draftPage{
+		Drafts:     drafts,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *ApiConfig) HandleGetDraft(w http.ResponseWriter, r *http.Request) {
+	draft, ok := cfg.draftForRequest(w, r)
+	if !ok {
+		return
+	}
+	res.RespondWithJSON(w, http.StatusOK, toDraft(draft))
+}
+
+// HandleUpdateDraft replaces the contents of a draft.
+func (cfg *ApiConfig) HandleUpdateDraft(w http.ResponseWriter, r *http.Request) {
+	draft, ok := cfg.draftForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Body        string     `json:"body"`
+		InReplyToID *uuid.UUID `json:"in_reply_to_id"`
+		QuoteOfID   *uuid.UUID `json:"quote_of_id"`
+		Visibility  string     `json:"visibility"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	err := decoder.Decode(&params)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	visibility, err := parseVisibility(params.Visibility)
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	updated, err := cfg.DB.UpdateDraft(context.Background(), database.UpdateDraftParams{
+		ID:          draft.ID,
+		UserID:      draft.UserID,
+		Body:        params.Body,
+		InReplyToID: toNullUUID(params.InReplyToID),
+		QuoteOfID:   toNullUUID(params.QuoteOfID),
+		Visibility:  visibility,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		res.RespondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return
+	}
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating draft", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusOK, toDraft(updated))
+}
+
+func (cfg *ApiConfig) HandleDeleteDraft(w http.ResponseWriter, r *http.Request) {
+	draft, ok := cfg.draftForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.DB.DeleteDraft(context.Background(), database.DeleteDraftParams{
+		ID:     draft.ID,
+		UserID: draft.UserID,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error deleting draft", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// HandlePublishDraft turns a draft into a chirp. The draft is deleted in
+// the same transaction that creates the chirp, so publishing twice at once
+// yields one chirp and a 404.
+func (cfg *ApiConfig) HandlePublishDraft(w http.ResponseWriter, r *http.Request) {
+	draft, ok := cfg.draftForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error publishing draft", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	// Publish what is stored once the row is ours, not what was read above.
+	draft, err = qtx.DeleteDraft(context.Background(), database.DeleteDraftParams{
+		ID:     draft.ID,
+		UserID: draft.UserID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		res.RespondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return
+	}
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error publishing draft", err)
+		return
+	}
+
+	cleaned, err := validateChirp(draft.Body)
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "error sanitizng chirp", err)
+		return
+	}
+
+	targets, err := resolveChirpTargets(qtx, draft.UserID, draft.InReplyToID, draft.QuoteOfID)
+	if err != nil {
+		respondWithChirpTargetError(w, err)
+		return
+	}
+
+	chirp, mentionedIDs, err := insertChirp(qtx, draft.UserID, cleaned, draft.Visibility, targets)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error publishing draft", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error publishing draft", err)
+		return
+	}
+
+	response := toChirp(chirp)
+	err = cfg.decorateChirps(uuid.NullUUID{UUID: draft.UserID, Valid: true}, &response)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error publishing draft", err)
+		return
+	}
+	cfg.announceChirp(response, targets, mentionedIDs)
+	res.RespondWithJSON(w, http.StatusCreated, response)
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	"github.com/sebmaz93/gocial_server/internal/notifications"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+type FollowRequest struct {
+	UserID      uuid.UUID `json:"user_id"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// followRequestPage is the envelope for paginated follow request listings.
+type followRequestPage struct {
+	Requests   []FollowRequest `json:"requests"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// HandleGetFollowRequests lists the pending requests to follow the
+// authenticated user, newest first.
+func (cfg *ApiConfig) HandleGetFollowRequests(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.DB.GetFollowRequests(context.Background(), database.GetFollowRequestsParams{
+		TargetID:        userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching follow requests", err)
+		return
+	}
+
+	rows, nextCursor := paginate(rows, page, func(row database.GetFollowRequestsRow) pageCursor {
+		return pageCursor{CreatedAt: row.RequestedAt, ID: row.UserID}
+	})
+	requests := []FollowRequest{}
+	for _, row := range rows {
+		requests = append(requests, FollowRequest{
+			UserID:      row.UserID,
+			RequestedAt: row.RequestedAt,
+		})
+	}
+	res.RespondWithJSON(w, http.StatusOK, followRequestPage{
+		Requests:   requests,
+		NextCursor: nextCursor,
+	})
+}
+
+// HandleApproveFollowRequest turns a pending request from `userID` into a
+// follow of the authenticated user.
+func (cfg *ApiConfig) HandleApproveFollowRequest(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error approving follow request", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	deleted, err := qtx.DeleteFollowRequest(context.Background(), database.DeleteFollowRequestParams{
+		RequesterID: requesterID,
+		TargetID:    userID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error approving follow request", err)
+		return
+	}
+	if deleted == 0 {
+		res.RespondWithError(w, http.StatusNotFound, "Follow request not found", nil)
+		return
+	}
+	inserted, err := qtx.FollowUser(context.Background(), database.FollowUserParams{
+		FollowerID: requesterID,
+		FolloweeID: userID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error approving follow request", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error approving follow request", err)
+		return
+	}
+	if inserted > 0 {
+		cfg.Notifier.Notify(userID, notifications.TypeFollow, requesterID, uuid.Nil)
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// HandleDenyFollowRequest drops a pending request from `userID` without
+// telling the requester.
+func (cfg *ApiConfig) HandleDenyFollowRequest(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	deleted, err := cfg.DB.DeleteFollowRequest(context.Background(), database.DeleteFollowRequestParams{
+		RequesterID: requesterID,
+		TargetID:    userID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error denying follow request", err)
+		return
+	}
+	if deleted == 0 {
+		res.RespondWithError(w, http.StatusNotFound, "Follow request not found", nil)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	"github.com/sebmaz93/gocial_server/internal/notifications"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+type FollowEntry struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// followPage is the envelope for paginated follower/following listings.
+type followPage struct {
+	Users      []FollowEntry `json:"users"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+func (cfg *ApiConfig) HandleFollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	if followeeID == userID {
+		res.RespondWithError(w, http.StatusBadRequest, "You can't follow yourself", nil)
+		return
+	}
+
+	followee, err := cfg.DB.GetUserByID(context.Background(), followeeID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error following user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	// HandleBlockUser takes the same lock, so a block can't land between
+	// the check and the follow and leave the follow behind.
+	err = qtx.LockUserPair(context.Background(), database.LockUserPairParams{
+		UserA: userID,
+		UserB: followeeID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error following user", err)
+		return
+	}
+	blocked, err := isBlocked(qtx, userID, followeeID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error following user", err)
+		return
+	}
+	if blocked {
+		res.RespondWithError(w, http.StatusForbidden, "You can't follow this user", nil)
+		return
+	}
+
+	// Private accounts approve their followers, so following one only asks.
+	if followee.IsPrivate {
+		_, err = qtx.CreateFollowRequest(context.Background(), database.CreateFollowRequestParams{
+			RequesterID: userID,
+			TargetID:    followeeID,
+		})
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error following user", err)
+			return
+		}
+		type response struct {
+			Status string `json:"status"`
+		}
+		res.RespondWithJSON(w, http.StatusAccepted, response{Status: "requested"})
+		return
+	}
+
+	inserted, err := qtx.FollowUser(context.Background(), database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error following user", err)
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error following user", err)
+		return
+	}
+	if inserted > 0 {
+		cfg.Notifier.Notify(followeeID, notifications.TypeFollow, userID, uuid.Nil)
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *ApiConfig) HandleUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	err = cfg.DB.UnfollowUser(context.Background(), database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error unfollowing user", err)
+		return
+	}
+	// Unfollowing also withdraws a request that is still pending.
+	_, err = cfg.DB.DeleteFollowRequest(context.Background(), database.DeleteFollowRequestParams{
+		RequesterID: userID,
+		TargetID:    followeeID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error unfollowing user", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *ApiConfig) HandleGetFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.DB.GetFollowers(context.Background(), database.GetFollowersParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching followers", err)
+		return
+	}
+
+	rows, nextCursor := paginate(rows, page, func(row database.GetFollowersRow) pageCursor {
+		return pageCursor{CreatedAt: row.FollowedAt, ID: row.UserID}
+	})
+	users := []FollowEntry{}
+	for _, row := range rows {
+		users = append(users, FollowEntry{
+			UserID:     row.UserID,
+			FollowedAt: row.FollowedAt,
+		})
+	}
+	res.RespondWithJSON(w, http.StatusOK, followPage{
+		Users:      users,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *ApiConfig) HandleGetFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.DB.GetFollowing(context.Background(), database.GetFollowingParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching following", err)
+		return
+	}
+
+	rows, nextCursor := paginate(rows, page, func(row database.GetFollowingRow) pageCursor {
+		return pageCursor{CreatedAt: row.FollowedAt, ID: row.UserID}
+	})
+	users := []FollowEntry{}
+	for _, row := range rows {
+		users = append(users, FollowEntry{
+			UserID:     row.UserID,
+			FollowedAt: row.FollowedAt,
+		})
+	}
+	res.RespondWithJSON(w, http.StatusOK, followPage{
+		Users:      users,
+		NextCursor: nextCursor,
+	})
+}
@@ -1,18 +1,28 @@
 package handlers
 
 import (
+	"database/sql"
 	"net/http"
 	"sync/atomic"
 
+	"github.com/sebmaz93/gocial_server/internal/auth"
 	"github.com/sebmaz93/gocial_server/internal/database"
+	"github.com/sebmaz93/gocial_server/internal/notifications"
+	"github.com/sebmaz93/gocial_server/internal/revocation"
+	"github.com/sebmaz93/gocial_server/internal/stream"
 )
 
 type ApiConfig struct {
 	FileserverHits atomic.Int32
 	DB             *database.Queries
+	DBConn         *sql.DB
+	Notifier       *notifications.Notifier
+	Stream         *stream.Hub
 	ENV            string
-	JWTSecret      string
+	JWTKeys        *auth.KeySet
+	Revocations    *revocation.Store
 	POLKA          string
+	AdminKey       string
 }
 
 func HandlerHealth(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	"github.com/sebmaz93/gocial_server/internal/notifications"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+func (cfg *ApiConfig) HandleLikeChirp(w http.ResponseWriter, r *http.Request) {
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error liking Chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	chirp, err := qtx.GetVisibleChirpByID(context.Background(), database.GetVisibleChirpByIDParams{
+		ID:       parsedChirpID,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil || chirp.DeletedAt.Valid {
+		res.RespondWithError(w, http.StatusNotFound, "Error fetching Chirp", err)
+		return
+	}
+
+	inserted, err := qtx.LikeChirp(context.Background(), database.LikeChirpParams{
+		UserID:  userID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error liking Chirp", err)
+		return
+	}
+	// Liking twice is a no-op, so only count the like that was stored.
+	if inserted > 0 {
+		err = qtx.IncrementLikeCount(context.Background(), chirp.ID)
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error liking Chirp", err)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error liking Chirp", err)
+		return
+	}
+	if inserted > 0 {
+		cfg.Notifier.Notify(chirp.UserID, notifications.TypeLike, userID, chirp.ID)
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *ApiConfig) HandleUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error unliking Chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	deleted, err := qtx.UnlikeChirp(context.Background(), database.UnlikeChirpParams{
+		UserID:  userID,
+		ChirpID: parsedChirpID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error unliking Chirp", err)
+		return
+	}
+	if deleted > 0 {
+		err = qtx.DecrementLikeCount(context.Background(), parsedChirpID)
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error unliking Chirp", err)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error unliking Chirp", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// HandleGetUserLikes lists the chirps a user has liked, most recent like
+// first.
+func (cfg *ApiConfig) HandleGetUserLikes(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	rows, err := cfg.DB.GetLikesByUser(context.Background(), database.GetLikesByUserParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		ViewerID:        viewerID,
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching likes", err)
+		return
+	}
+
+	rows, nextCursor := paginate(rows, page, func(row database.GetLikesByUserRow) pageCursor {
+		return pageCursor{CreatedAt: row.LikedAt, ID: row.Chirp.ID}
+	})
+	chirps := []Chirp{}
+	for _, row := range rows {
+		chirps = append(chirps, toChirp(row.Chirp))
+	}
+
+	err = cfg.decorateChirps(viewerID, chirpPtrs(chirps)...)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching likes", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusOK, chirpPage{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+const maxListNameLength = 50
+
+var errInvalidListName = errors.New("list name must be 1-50 characters")
+
+// List is a named set of accounts whose chirps make up its own timeline.
+// Lists are private to the user who created them.
+type List struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ListMember struct {
+	UserID  uuid.UUID `json:"user_id"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+type listPage struct {
+	Lists      []List `json:"lists"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type listMemberPage struct {
+	Members    []ListMember `json:"members"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+func toList(l database.List) List {
+	return List{
+		ID:        l.ID,
+		Name:      l.Name,
+		CreatedAt: l.CreatedAt,
+		UpdatedAt: l.UpdatedAt,
+	}
+}
+
+// parseListName trims a list name and checks its length.
+func parseListName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxListNameLength {
+		return "", errInvalidListName
+	}
+	return name, nil
+}
+
+// listForRequest authenticates the request and loads the list in its path.
+// Lists owned by someone else are reported as not found.
+func (cfg *ApiConfig) listForRequest(w http.ResponseWriter, r *http.Request) (database.List, bool) {
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid list ID", err)
+		return database.List{}, false
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return database.List{}, false
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return database.List{}, false
+	}
+
+	list, err := cfg.DB.GetListForUser(context.Background(), database.GetListForUserParams{
+		ID:     listID,
+		UserID: userID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusNotFound, "List not found", err)
+		return database.List{}, false
+	}
+	return list, true
+}
+
+func (cfg *ApiConfig) HandleCreateList(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	type parameters struct {
+		Name string `json:"name"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	err = decoder.Decode(&params)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	name, err := parseListName(params.Name)
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	list, err := cfg.DB.CreateList(context.Background(), database.CreateListParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if isUniqueViolation(err) {
+		res.RespondWithError(w, http.StatusConflict, "You already have a list with that name", err)
+		return
+	}
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating list", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusCreated, toList(list))
+}
+
+// HandleGetLists pages through the authenticated user's lists, newest first.
+func (cfg *ApiConfig) HandleGetLists(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbLists, err := cfg.DB.GetListsForUser(context.Background(), database.GetListsForUserParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching lists", err)
+		return
+	}
+
+	dbLists, nextCursor := paginate(dbLists, page, func(l database.List) pageCursor {
+		return pageCursor{CreatedAt: l.CreatedAt, ID: l.ID}
+	})
+	lists := []List{}
+	for _, l := range dbLists {
+		lists = append(lists, toList(l))
+	}
+	res.RespondWithJSON(w, http.StatusOK, listPage{
+		Lists:      lists,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *ApiConfig) HandleGetList(w http.ResponseWriter, r *http.Request) {
+	list, ok := cfg.listForRequest(w, r)
+	if !ok {
+		return
+	}
+	res.RespondWithJSON(w, http.StatusOK, toList(list))
+}
+
+func (cfg *ApiConfig) HandleRenameList(w http.ResponseWriter, r *http.Request) {
+	list, ok := cfg.listForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Name string `json:"name"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	err := decoder.Decode(&params)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	name, err := parseListName(params.Name)
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	renamed, err := cfg.DB.RenameList(context.Background(), database.RenameListParams{
+		ID:     list.ID,
+		UserID: list.UserID,
+		Name:   name,
+	})
+	if isUniqueViolation(err) {
+		res.RespondWithError(w, http.StatusConflict, "You already have a list with that name", err)
+		return
+	}
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error renaming list", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusOK, toList(renamed))
+}
+
+func (cfg *ApiConfig) HandleDeleteList(w http.ResponseWriter, r *http.Request) {
+	list, ok := cfg.listForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.DB.DeleteList(context.Background(), database.DeleteListParams{
+		ID:     list.ID,
+		UserID: list.UserID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error deleting list", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *ApiConfig) HandleGetListMembers(w http.ResponseWriter, r *http.Request) {
+	list, ok := cfg.listForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.DB.GetListMembers(context.Background(), database.GetListMembersParams{
+		ListID:          list.ID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching list members", err)
+		return
+	}
+
+	rows, nextCursor := paginate(rows, page, func(row database.GetListMembersRow) pageCursor {
+		return pageCursor{CreatedAt: row.AddedAt, ID: row.UserID}
+	})
+	members := []ListMember{}
+	for _, row := range rows {
+		members = append(members, ListMember{
+			UserID:  row.UserID,
+			AddedAt: row.AddedAt,
+		})
+	}
+	res.RespondWithJSON(w, http.StatusOK, listMemberPage{
+		Members:    members,
+		NextCursor: nextCursor,
+	})
+}
+
+// HandleAddListMember adds `user_id` to a list. Members are not told, and
+// adding someone twice is a no-op.
+func (cfg *ApiConfig) HandleAddListMember(w http.ResponseWriter, r *http.Request) {
+	list, ok := cfg.listForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	err := decoder.Decode(&params)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	_, err = cfg.DB.GetUserByID(context.Background(), params.UserID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+
+	err = cfg.DB.AddListMember(context.Background(), database.AddListMemberParams{
+		ListID: list.ID,
+		UserID: params.UserID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error adding list member", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *ApiConfig) HandleRemoveListMember(w http.ResponseWriter, r *http.Request) {
+	list, ok := cfg.listForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	err = cfg.DB.RemoveListMember(context.Background(), database.RemoveListMemberParams{
+		ListID: list.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error removing list member", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// HandleGetListTimeline is the timeline of a list: chirps and rechirps by
+// its members, newest first, filtered as they would be for the owner.
+func (cfg *ApiConfig) HandleGetListTimeline(w http.ResponseWriter, r *http.Request) {
+	list, ok := cfg.listForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	ownerID := uuid.NullUUID{UUID: list.UserID, Valid: true}
+	rows, err := cfg.DB.GetListTimeline(context.Background(), database.GetListTimelineParams{
+		ListID:          list.ID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		ViewerID:        ownerID,
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching list timeline", err)
+		return
+	}
+
+	rows, nextCursor := paginate(rows, page, func(row database.GetListTimelineRow) pageCursor {
+		return pageCursor{CreatedAt: row.ItemCreatedAt, ID: row.ItemID}
+	})
+	chirps := []Chirp{}
+	for _, row := range rows {
+		chirps = append(chirps, feedItem(row.Chirp, row.ItemID, row.ItemCreatedAt, row.RechirpedBy))
+	}
+	err = cfg.decorateChirps(ownerID, chirpPtrs(chirps)...)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching list timeline", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusOK, chirpPage{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseListName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "Simple", input: "Gophers", want: "Gophers"},
+		{name: "Trimmed", input: "  Friends \n", want: "Friends"},
+		{name: "Empty", input: "", wantErr: true},
+		{name: "Only spaces", input: "   ", wantErr: true},
+		{name: "Longest allowed", input: strings.Repeat("é", maxListNameLength), want: strings.Repeat("é", maxListNameLength)},
+		{name: "Too long", input: strings.Repeat("a", maxListNameLength+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseListName(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseListName(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseListName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+// Mention is an @handle in a chirp body that resolved to a user. Start and
+// End are byte offsets into the body, End being exclusive.
+type Mention struct {
+	UserID uuid.UUID `json:"user_id"`
+	Handle string    `json:"handle"`
+	Start  int32     `json:"start"`
+	End    int32     `json:"end"`
+}
+
+// mentionSpan is an @handle found in a body before it has been resolved.
+type mentionSpan struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// extractMentions returns every @handle in body with its byte offsets. An
+// '@' that follows a handle character (as in an email address) is ignored,
+// and handles longer than the maximum are not mentions at all.
+func extractMentions(body string) []mentionSpan {
+	spans := []mentionSpan{}
+	for i := 0; i < len(body); i++ {
+		if body[i] != '@' || (i > 0 && isHandleByte(body[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(body) && isHandleByte(body[end]) {
+			end++
+		}
+
+		handle := body[i+1 : end]
+		if validHandle(handle) {
+			spans = append(spans, mentionSpan{
+				Handle: handle,
+				Start:  i,
+				End:    end,
+			})
+		}
+		i = end - 1
+	}
+	return spans
+}
+
+// setChirpMentions replaces the stored mentions of a chirp with the
+// @handles in its body that belong to existing users, and returns the
+// distinct users mentioned.
+func setChirpMentions(q *database.Queries, chirp database.Chirp) ([]uuid.UUID, error) {
+	err := q.DeleteChirpMentions(context.Background(), chirp.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	spans := extractMentions(chirp.Body)
+	if len(spans) == 0 {
+		return nil, nil
+	}
+
+	handles := make([]string, len(spans))
+	for i, span := range spans {
+		handles[i] = strings.ToLower(span.Handle)
+	}
+	// Users who blocked the author, or whom the author blocked, are left
+	// unmentioned.
+	users, err := q.GetUsersByHandles(context.Background(), database.GetUsersByHandlesParams{
+		Handles:  handles,
+		AuthorID: chirp.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make(map[string]uuid.UUID, len(users))
+	for _, user := range users {
+		userIDs[strings.ToLower(user.Handle.String)] = user.ID
+	}
+
+	params := database.CreateMentionsParams{ChirpID: chirp.ID}
+	var mentioned []uuid.UUID
+	seen := map[uuid.UUID]struct{}{}
+	for _, span := range spans {
+		userID, ok := userIDs[strings.ToLower(span.Handle)]
+		if !ok {
+			continue
+		}
+		params.UserIds = append(params.UserIds, userID)
+		params.StartOffsets = append(params.StartOffsets, int32(span.Start))
+		params.EndOffsets = append(params.EndOffsets, int32(span.End))
+		if _, ok := seen[userID]; !ok {
+			seen[userID] = struct{}{}
+			mentioned = append(mentioned, userID)
+		}
+	}
+	if len(params.UserIds) == 0 {
+		return nil, nil
+	}
+	return mentioned, q.CreateMentions(context.Background(), params)
+}
+
+func (cfg *ApiConfig) embedMentions(chirps []*Chirp) error {
+	ids := make([]uuid.UUID, len(chirps))
+	for i, chirp := range chirps {
+		ids[i] = chirp.ID
+	}
+
+	rows, err := cfg.DB.GetMentionsForChirps(context.Background(), ids)
+	if err != nil {
+		return err
+	}
+	mentions := make(map[uuid.UUID][]Mention, len(rows))
+	for _, row := range rows {
+		mentions[row.ChirpID] = append(mentions[row.ChirpID], Mention{
+			UserID: row.UserID,
+			Handle: row.Handle.String,
+			Start:  row.StartOffset,
+			End:    row.EndOffset,
+		})
+	}
+
+	for _, chirp := range chirps {
+		if m, ok := mentions[chirp.ID]; ok {
+			chirp.Mentions = m
+		} else {
+			chirp.Mentions = []Mention{}
+		}
+	}
+	return nil
+}
+
+// HandleGetMentions lists the chirps that mention the authenticated user,
+// newest first.
+func (cfg *ApiConfig) HandleGetMentions(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbChirps, err := cfg.DB.GetChirpsMentioningUser(context.Background(), database.GetChirpsMentioningUserParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching mentions", err)
+		return
+	}
+
+	dbChirps, nextCursor := paginate(dbChirps, page, func(c database.Chirp) pageCursor {
+		return pageCursor{CreatedAt: c.CreatedAt, ID: c.ID}
+	})
+	chirps := toChirps(dbChirps)
+	err = cfg.decorateChirps(uuid.NullUUID{UUID: userID, Valid: true}, chirpPtrs(chirps)...)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching mentions", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusOK, chirpPage{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
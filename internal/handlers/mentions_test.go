@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []mentionSpan
+	}{
+		{
+			name: "No mentions",
+			body: "just a chirp",
+			want: []mentionSpan{},
+		},
+		{
+			name: "Byte offsets",
+			body: "hi @alice and @Bob_1",
+			want: []mentionSpan{
+				{Handle: "alice", Start: 3, End: 9},
+				{Handle: "Bob_1", Start: 14, End: 20},
+			},
+		},
+		{
+			name: "Offsets count bytes after multibyte text",
+			body: "café @alice",
+			want: []mentionSpan{{Handle: "alice", Start: 6, End: 12}},
+		},
+		{
+			name: "Email addresses are not mentions",
+			body: "mail me at bob@example.com",
+			want: []mentionSpan{},
+		},
+		{
+			name: "Punctuation ends a mention",
+			body: "(@alice), @bob!",
+			want: []mentionSpan{
+				{Handle: "alice", Start: 1, End: 7},
+				{Handle: "bob", Start: 10, End: 14},
+			},
+		},
+		{
+			name: "Too short or too long",
+			body: "@ab @" + "abcdefghijklmnopqrstuvwxyz12345",
+			want: []mentionSpan{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractMentions(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractMentions(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+	"github.com/sebmaz93/gocial_server/internal/stream"
+)
+
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	ActorID   *uuid.UUID `json:"actor_id"`
+	ChirpID   *uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+type notificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	UnreadCount   int64          `json:"unread_count"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+func toNotification(n database.Notification) Notification {
+	notification := Notification{
+		ID:        n.ID,
+		Type:      n.Type,
+		CreatedAt: n.CreatedAt,
+	}
+	if n.ActorID.Valid {
+		notification.ActorID = &n.ActorID.UUID
+	}
+	if n.ChirpID.Valid {
+		notification.ChirpID = &n.ChirpID.UUID
+	}
+	if n.ReadAt.Valid {
+		notification.ReadAt = &n.ReadAt.Time
+	}
+	return notification
+}
+
+// PublishNotification pushes a stored notification to its recipient's live
+// connections.
+func (cfg *ApiConfig) PublishNotification(n database.Notification) {
+	payload, err := json.Marshal(toNotification(n))
+	if err != nil {
+		log.Printf("Error encoding notification: %s", err)
+		return
+	}
+	cfg.Stream.Publish(stream.Event{
+		Type:        stream.EventNotification,
+		RecipientID: uuid.NullUUID{UUID: n.UserID, Valid: true},
+		Data:        payload,
+	})
+}
+
+// HandleGetNotifications lists the authenticated user's notifications,
+// newest first. Pass `unread=true` to leave out the ones already read.
+func (cfg *ApiConfig) HandleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	query := r.URL.Query()
+	page, err := parsePageRequest(query)
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbNotifications, err := cfg.DB.GetNotifications(context.Background(), database.GetNotificationsParams{
+		UserID:          userID,
+		UnreadOnly:      query.Get("unread") == "true",
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching notifications", err)
+		return
+	}
+	unread, err := cfg.DB.CountUnreadNotifications(context.Background(), userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching notifications", err)
+		return
+	}
+
+	dbNotifications, nextCursor := paginate(dbNotifications, page, func(n database.Notification) pageCursor {
+		return pageCursor{CreatedAt: n.CreatedAt, ID: n.ID}
+	})
+	notifications := []Notification{}
+	for _, n := range dbNotifications {
+		notifications = append(notifications, toNotification(n))
+	}
+	res.RespondWithJSON(w, http.StatusOK, notificationPage{
+		Notifications: notifications,
+		UnreadCount:   unread,
+		NextCursor:    nextCursor,
+	})
+}
+
+func (cfg *ApiConfig) HandleGetUnreadNotificationCount(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	unread, err := cfg.DB.CountUnreadNotifications(context.Background(), userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error counting notifications", err)
+		return
+	}
+
+	type response struct {
+		UnreadCount int64 `json:"unread_count"`
+	}
+	res.RespondWithJSON(w, http.StatusOK, response{
+		UnreadCount: unread,
+	})
+}
+
+func (cfg *ApiConfig) HandleMarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	notificationID, err := uuid.Parse(r.PathValue("notificationID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid notification ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	updated, err := cfg.DB.MarkNotificationRead(context.Background(), database.MarkNotificationReadParams{
+		ID:     notificationID,
+		UserID: userID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating notification", err)
+		return
+	}
+	if updated == 0 {
+		res.RespondWithError(w, http.StatusNotFound, "Notification not found", nil)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *ApiConfig) HandleMarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	err = cfg.DB.MarkAllNotificationsRead(context.Background(), userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating notifications", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pageRequest holds the `limit` and `cursor` query parameters shared by every
+// paginated listing. A nil Cursor means the first page.
+type pageRequest struct {
+	Limit  int32
+	Cursor *pageCursor
+}
+
+// pageCursor is the (created_at, id) keyset position of the last row a client
+// has seen. It is handed out base64 encoded so clients treat it as opaque.
+type pageCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+	// Rank is only set for listings ordered by search relevance.
+	Rank *float32
+}
+
+func parsePageRequest(query url.Values) (pageRequest, error) {
+	page := pageRequest{Limit: defaultPageLimit}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return pageRequest{}, errors.New("limit must be a positive integer")
+		}
+		page.Limit = int32(min(n, maxPageLimit))
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		decoded, err := decodeCursor(cursor)
+		if err != nil {
+			return pageRequest{}, err
+		}
+		page.Cursor = &decoded
+	}
+
+	return page, nil
+}
+
+// fetchLimit is the number of rows to ask the database for: one more than the
+// page size, so we know whether a next page exists without a count query.
+func (p pageRequest) fetchLimit() int32 {
+	return p.Limit + 1
+}
+
+func (p pageRequest) cursorCreatedAt() sql.NullTime {
+	if p.Cursor == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true}
+}
+
+func (p pageRequest) cursorID() uuid.NullUUID {
+	if p.Cursor == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+func (p pageRequest) cursorRank() sql.NullFloat64 {
+	if p.Cursor == nil || p.Cursor.Rank == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: float64(*p.Cursor.Rank), Valid: true}
+}
+
+// paginate trims the extra row fetched by fetchLimit and returns the cursor
+// for the next page, or "" when rows was the last page.
+func paginate[T any](rows []T, page pageRequest, key func(T) pageCursor) ([]T, string) {
+	if len(rows) <= int(page.Limit) {
+		return rows, ""
+	}
+	rows = rows[:page.Limit]
+	return rows, encodeCursor(key(rows[len(rows)-1]))
+}
+
+func encodeCursor(c pageCursor) string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	if c.Rank != nil {
+		raw += "|" + strconv.FormatFloat(float64(*c.Rank), 'g', -1, 32)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	errInvalid := errors.New("invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, errInvalid
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 2 && len(parts) != 3 {
+		return pageCursor{}, errInvalid
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return pageCursor{}, errInvalid
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return pageCursor{}, errInvalid
+	}
+	cursor := pageCursor{CreatedAt: createdAt, ID: id}
+	if len(parts) == 3 {
+		rank, err := strconv.ParseFloat(parts[2], 32)
+		if err != nil {
+			return pageCursor{}, errInvalid
+		}
+		rank32 := float32(rank)
+		cursor.Rank = &rank32
+	}
+	return cursor, nil
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := pageCursor{
+		CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC),
+		ID:        uuid.New(),
+	}
+	got, err := decodeCursor(encodeCursor(want))
+	if err != nil {
+		t.Fatalf("decodeCursor() error = %v", err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID {
+		t.Errorf("decodeCursor() = %v, want %v", got, want)
+	}
+}
+
+func TestParsePageRequest(t *testing.T) {
+	validCursor := encodeCursor(pageCursor{CreatedAt: time.Now(), ID: uuid.New()})
+
+	tests := []struct {
+		name       string
+		query      url.Values
+		wantLimit  int32
+		wantCursor bool
+		wantErr    bool
+	}{
+		{
+			name:      "Defaults",
+			query:     url.Values{},
+			wantLimit: defaultPageLimit,
+		},
+		{
+			name:      "Custom limit",
+			query:     url.Values{"limit": {"5"}},
+			wantLimit: 5,
+		},
+		{
+			name:      "Limit is capped",
+			query:     url.Values{"limit": {"5000"}},
+			wantLimit: maxPageLimit,
+		},
+		{
+			name:    "Zero limit",
+			query:   url.Values{"limit": {"0"}},
+			wantErr: true,
+		},
+		{
+			name:    "Non numeric limit",
+			query:   url.Values{"limit": {"ten"}},
+			wantErr: true,
+		},
+		{
+			name:       "Valid cursor",
+			query:      url.Values{"cursor": {validCursor}},
+			wantLimit:  defaultPageLimit,
+			wantCursor: true,
+		},
+		{
+			name:    "Garbage cursor",
+			query:   url.Values{"cursor": {"not-a-cursor"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := parsePageRequest(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parsePageRequest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if page.Limit != tt.wantLimit {
+				t.Errorf("parsePageRequest() limit = %v, want %v", page.Limit, tt.wantLimit)
+			}
+			if (page.Cursor != nil) != tt.wantCursor {
+				t.Errorf("parsePageRequest() cursor = %v, wantCursor %v", page.Cursor, tt.wantCursor)
+			}
+		})
+	}
+}
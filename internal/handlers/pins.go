@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+// How many chirps a user can keep pinned to their profile at once.
+const (
+	maxPinnedChirps    = 3
+	maxPinnedChirpsRed = 10
+)
+
+func pinLimit(user database.User) int64 {
+	if user.IsChirpyRed {
+		return maxPinnedChirpsRed
+	}
+	return maxPinnedChirps
+}
+
+// HandlePinChirp pins one of the authenticated user's own chirps to their
+// profile. The user's row is locked while the pins are counted, so
+// concurrent requests can't get past the limit together.
+func (cfg *ApiConfig) HandlePinChirp(w http.ResponseWriter, r *http.Request) {
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error pinning Chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	user, err := qtx.GetUserByIDForUpdate(context.Background(), userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+
+	chirp, err := qtx.GetChirpByIDForUpdate(context.Background(), parsedChirpID)
+	if err != nil || chirp.DeletedAt.Valid {
+		res.RespondWithError(w, http.StatusNotFound, "Error fetching Chirp", err)
+		return
+	}
+	if chirp.UserID != userID {
+		res.RespondWithError(w, http.StatusForbidden, "You can only pin your own chirps", nil)
+		return
+	}
+	if chirp.PinnedAt.Valid {
+		res.RespondWithJSON(w, http.StatusNoContent, nil)
+		return
+	}
+
+	pinned, err := qtx.CountPinnedChirps(context.Background(), userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error pinning Chirp", err)
+		return
+	}
+	if pinned >= pinLimit(user) {
+		res.RespondWithError(w, http.StatusConflict, "Pinned chirp limit reached", nil)
+		return
+	}
+
+	err = qtx.PinChirp(context.Background(), chirp.ID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error pinning Chirp", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error pinning Chirp", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *ApiConfig) HandleUnpinChirp(w http.ResponseWriter, r *http.Request) {
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	err = cfg.DB.UnpinChirp(context.Background(), database.UnpinChirpParams{
+		ID:     parsedChirpID,
+		UserID: userID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error unpinning Chirp", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
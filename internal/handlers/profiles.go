@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxAvatarURLLength   = 2048
+)
+
+// Profile is the public view of a user. It never includes the email.
+type Profile struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Handle         string    `json:"handle"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	AvatarURL      string    `json:"avatar_url"`
+	IsChirpRed     bool      `json:"is_chirpy_red"`
+	IsPrivate      bool      `json:"is_private"`
+	ChirpCount     int64     `json:"chirp_count"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+}
+
+// profileFields holds the optional profile fields of a create or update
+// request. Fields left out of the request stay invalid so updates keep the
+// stored value.
+type profileFields struct {
+	DisplayName sql.NullString
+	Bio         sql.NullString
+	AvatarURL   sql.NullString
+}
+
+func parseProfileFields(displayName, bio, avatarURL *string) (profileFields, error) {
+	fields := profileFields{}
+	if displayName != nil {
+		name := strings.TrimSpace(*displayName)
+		if utf8.RuneCountInString(name) > maxDisplayNameLength {
+			return profileFields{}, fmt.Errorf("display name must be at most %d characters", maxDisplayNameLength)
+		}
+		fields.DisplayName = sql.NullString{String: name, Valid: true}
+	}
+	if bio != nil {
+		text := strings.TrimSpace(*bio)
+		if utf8.RuneCountInString(text) > maxBioLength {
+			return profileFields{}, fmt.Errorf("bio must be at most %d characters", maxBioLength)
+		}
+		fields.Bio = sql.NullString{String: text, Valid: true}
+	}
+	if avatarURL != nil {
+		err := validateAvatarURL(*avatarURL)
+		if err != nil {
+			return profileFields{}, err
+		}
+		fields.AvatarURL = sql.NullString{String: *avatarURL, Valid: true}
+	}
+	return fields, nil
+}
+
+// validateAvatarURL accepts an empty string, which clears the avatar, or an
+// absolute http(s) URL.
+func validateAvatarURL(s string) error {
+	if s == "" {
+		return nil
+	}
+	if len(s) > maxAvatarURLLength {
+		return fmt.Errorf("avatar url must be at most %d bytes", maxAvatarURLLength)
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("avatar url must be an absolute http or https url")
+	}
+	return nil
+}
+
+// HandleGetUserProfile serves GET /api/users/{handle}. Handles are matched
+// case-insensitively.
+func (cfg *ApiConfig) HandleGetUserProfile(w http.ResponseWriter, r *http.Request) {
+	handle := r.PathValue("handle")
+	if !validHandle(handle) {
+		res.RespondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	row, err := cfg.DB.GetUserProfileByHandle(context.Background(), handle)
+	if errors.Is(err, sql.ErrNoRows) {
+		res.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching user", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusOK, Profile{
+		ID:             row.ID,
+		CreatedAt:      row.CreatedAt,
+		Handle:         row.Handle.String,
+		DisplayName:    row.DisplayName.String,
+		Bio:            row.Bio.String,
+		AvatarURL:      row.AvatarURL.String,
+		IsChirpRed:     row.IsChirpyRed,
+		IsPrivate:      row.IsPrivate,
+		ChirpCount:     row.ChirpCount,
+		FollowerCount:  row.FollowerCount,
+		FollowingCount: row.FollowingCount,
+	})
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidHandle(t *testing.T) {
+	tests := []struct {
+		handle string
+		want   bool
+	}{
+		{handle: "alice", want: true},
+		{handle: "Bob_42", want: true},
+		{handle: "ab", want: false},
+		{handle: strings.Repeat("a", 31), want: false},
+		{handle: "with space", want: false},
+		{handle: "café", want: false},
+		{handle: "dash-ed", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.handle, func(t *testing.T) {
+			if got := validHandle(tt.handle); got != tt.want {
+				t.Errorf("validHandle(%q) = %v, want %v", tt.handle, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAvatarURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{name: "Empty clears", url: "", wantErr: false},
+		{name: "HTTPS", url: "https://example.com/me.png", wantErr: false},
+		{name: "Relative", url: "/me.png", wantErr: true},
+		{name: "Other scheme", url: "javascript:alert(1)", wantErr: true},
+		{name: "Missing host", url: "https:///me.png", wantErr: true},
+		{name: "Too long", url: "https://example.com/" + strings.Repeat("a", maxAvatarURLLength), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAvatarURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateAvatarURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
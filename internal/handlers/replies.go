@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+// ThreadNode is a chirp together with the replies made to it.
+type ThreadNode struct {
+	Chirp
+	Replies []*ThreadNode `json:"replies"`
+}
+
+// HandleGetChirpReplies lists the direct replies to a chirp, oldest first.
+func (cfg *ApiConfig) HandleGetChirpReplies(w http.ResponseWriter, r *http.Request) {
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	dbChirps, err := cfg.DB.GetChirpReplies(context.Background(), database.GetChirpRepliesParams{
+		ChirpID:         parsedChirpID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		ViewerID:        viewerID,
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching replies", err)
+		return
+	}
+
+	dbChirps, nextCursor := paginate(dbChirps, page, func(c database.Chirp) pageCursor {
+		return pageCursor{CreatedAt: c.CreatedAt, ID: c.ID}
+	})
+	chirps := toChirps(dbChirps)
+	err = cfg.decorateChirps(viewerID, chirpPtrs(chirps)...)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching replies", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusOK, chirpPage{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+// HandleGetChirpThread returns the whole conversation the chirp belongs to,
+// as a tree rooted at the chirp that started it.
+func (cfg *ApiConfig) HandleGetChirpThread(w http.ResponseWriter, r *http.Request) {
+	parsedChirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	rows, err := cfg.DB.GetChirpThread(context.Background(), database.GetChirpThreadParams{
+		ChirpID:  parsedChirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching thread", err)
+		return
+	}
+
+	// Rows come back parents first, so every reply's parent is already in
+	// the map by the time the reply is reached. Replies under a chirp the
+	// viewer can't see are dropped with it.
+	nodes := make(map[uuid.UUID]*ThreadNode, len(rows))
+	threadChirps := make([]*Chirp, 0, len(rows))
+	var root *ThreadNode
+	for _, row := range rows {
+		node := &ThreadNode{
+			Chirp: toChirp(database.Chirp{
+				ID:          row.ID,
+				CreatedAt:   row.CreatedAt,
+				UpdatedAt:   row.UpdatedAt,
+				Body:        row.Body,
+				UserID:      row.UserID,
+				InReplyToID: row.InReplyToID,
+				ReplyCount:  row.ReplyCount,
+				DeletedAt:   row.DeletedAt,
+				LikeCount:   row.LikeCount,
+				QuoteOfID:   row.QuoteOfID,
+				Visibility:  row.Visibility,
+				PinnedAt:    row.PinnedAt,
+			}),
+			Replies: []*ThreadNode{},
+		}
+		nodes[row.ID] = node
+		threadChirps = append(threadChirps, &node.Chirp)
+		if row.Depth == 0 {
+			root = node
+			continue
+		}
+		if parent, ok := nodes[row.InReplyToID.UUID]; ok {
+			parent.Replies = append(parent.Replies, node)
+		}
+	}
+
+	if root == nil {
+		res.RespondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+		return
+	}
+
+	err = cfg.decorateChirps(viewerID, threadChirps...)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching thread", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusOK, root)
+}
@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+// maxScheduleAhead is how far in the future a chirp can be scheduled.
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+var (
+	errPublishAtPast    = errors.New("publish_at must be in the future")
+	errPublishAtTooLate = errors.New("publish_at can be at most a year ahead")
+)
+
+// ScheduledChirp is a chirp waiting to be published. Only its author can
+// see it until then.
+type ScheduledChirp struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Body        string     `json:"body"`
+	UserID      uuid.UUID  `json:"user_id"`
+	InReplyToID *uuid.UUID `json:"in_reply_to_id"`
+	QuoteOfID   *uuid.UUID `json:"quote_of_id"`
+	Visibility  string     `json:"visibility"`
+	PublishAt   time.Time  `json:"publish_at"`
+	// Failure says why the chirp couldn't be published when it fell due,
+	// e.g. because the chirp it replies to was deleted. Editing a failed
+	// chirp schedules it again.
+	Failure string `json:"failure,omitempty"`
+}
+
+type scheduledChirpPage struct {
+	ScheduledChirps []ScheduledChirp `json:"scheduled_chirps"`
+	NextCursor      string           `json:"next_cursor,omitempty"`
+}
+
+func toScheduledChirp(dbChirp database.ScheduledChirp) ScheduledChirp {
+	chirp := ScheduledChirp{
+		ID:         dbChirp.ID,
+		CreatedAt:  dbChirp.CreatedAt,
+		UpdatedAt:  dbChirp.UpdatedAt,
+		Body:       dbChirp.Body,
+		UserID:     dbChirp.UserID,
+		Visibility: dbChirp.Visibility,
+		PublishAt:  dbChirp.PublishAt,
+		Failure:    dbChirp.Failure.String,
+	}
+	if dbChirp.InReplyToID.Valid {
+		chirp.InReplyToID = &dbChirp.InReplyToID.UUID
+	}
+	if dbChirp.QuoteOfID.Valid {
+		chirp.QuoteOfID = &dbChirp.QuoteOfID.UUID
+	}
+	return chirp
+}
+
+func validatePublishAt(publishAt, now time.Time) error {
+	if !publishAt.After(now) {
+		return errPublishAtPast
+	}
+	if publishAt.Sub(now) > maxScheduleAhead {
+		return errPublishAtTooLate
+	}
+	return nil
+}
+
+// scheduleChirp answers a POST /api/chirps that carries publish_at. The
+// reply and quote targets are checked now so mistakes surface right away,
+// and again when the chirp is published.
+func (cfg *ApiConfig) scheduleChirp(w http.ResponseWriter, params database.CreateScheduledChirpParams) {
+	err := validatePublishAt(params.PublishAt, time.Now())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	_, err = resolveChirpTargets(cfg.DB, params.UserID, params.InReplyToID, params.QuoteOfID)
+	if err != nil {
+		respondWithChirpTargetError(w, err)
+		return
+	}
+
+	scheduled, err := cfg.DB.CreateScheduledChirp(context.Background(), params)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error scheduling chirp", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusAccepted, toScheduledChirp(scheduled))
+}
+
+// HandleGetScheduledChirps lists the authenticated user's scheduled chirps,
+// soonest first.
+func (cfg *ApiConfig) HandleGetScheduledChirps(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.DB.GetScheduledChirpsForUser(context.Background(), database.GetScheduledChirpsForUserParams{
+		UserID:          userID,
+		CursorPublishAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching scheduled chirps", err)
+		return
+	}
+
+	rows, nextCursor := paginate(rows, page, func(c database.ScheduledChirp) pageCursor {
+		return pageCursor{CreatedAt: c.PublishAt, ID: c.ID}
+	})
+	scheduled := []ScheduledChirp{}
+	for _, row := range rows {
+		scheduled = append(scheduled, toScheduledChirp(row))
+	}
+	res.RespondWithJSON(w, http.StatusOK, scheduledChirpPage{
+		ScheduledChirps: scheduled,
+		NextCursor:      nextCursor,
+	})
+}
+
+// HandleUpdateScheduledChirp changes the body, visibility or publish time
+// of a scheduled chirp. Fields left out of the request keep their values.
+func (cfg *ApiConfig) HandleUpdateScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Body       *string    `json:"body"`
+		Visibility *string    `json:"visibility"`
+		PublishAt  *time.Time `json:"publish_at"`
+	}
+
+	scheduledID, err := uuid.Parse(r.PathValue("scheduledID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid scheduled chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	requestBody := reqBody{}
+	defer r.Body.Close()
+	err = decoder.Decode(&requestBody)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	// The row lock keeps the worker from publishing the chirp halfway
+	// through the edit.
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating scheduled chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	scheduled, err := qtx.GetScheduledChirpForUpdate(context.Background(), database.GetScheduledChirpForUpdateParams{
+		ID:     scheduledID,
+		UserID: userID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusNotFound, "Scheduled chirp not found", err)
+		return
+	}
+
+	params := database.UpdateScheduledChirpParams{
+		ID:         scheduled.ID,
+		UserID:     userID,
+		Body:       scheduled.Body,
+		Visibility: scheduled.Visibility,
+		PublishAt:  scheduled.PublishAt,
+	}
+	if requestBody.Body != nil {
+		params.Body, err = validateChirp(*requestBody.Body)
+		if err != nil {
+			res.RespondWithError(w, http.StatusBadRequest, "error sanitizng chirp", err)
+			return
+		}
+	}
+	if requestBody.Visibility != nil {
+		params.Visibility, err = parseVisibility(*requestBody.Visibility)
+		if err != nil {
+			res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	}
+	if requestBody.PublishAt != nil {
+		err = validatePublishAt(*requestBody.PublishAt, time.Now())
+		if err != nil {
+			res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		params.PublishAt = *requestBody.PublishAt
+	}
+
+	updated, err := qtx.UpdateScheduledChirp(context.Background(), params)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating scheduled chirp", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating scheduled chirp", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusOK, toScheduledChirp(updated))
+}
+
+// HandleCancelScheduledChirp deletes a scheduled chirp before it goes out.
+// It is a POST to .../cancel because DELETE /api/chirps/scheduled/{id}
+// would clash with the DELETE /api/chirps/{chirpID}/... routes.
+func (cfg *ApiConfig) HandleCancelScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	scheduledID, err := uuid.Parse(r.PathValue("scheduledID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid scheduled chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	deleted, err := cfg.DB.DeleteScheduledChirp(context.Background(), database.DeleteScheduledChirpParams{
+		ID:     scheduledID,
+		UserID: userID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error cancelling scheduled chirp", err)
+		return
+	}
+	if deleted == 0 {
+		res.RespondWithError(w, http.StatusNotFound, "Scheduled chirp not found", nil)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// RunScheduledChirps publishes scheduled chirps as they fall due, checking
+// every interval until ctx is cancelled. Schedules live in Postgres, so
+// chirps that fell due while the server was down go out on start-up, and
+// several instances can run the worker at once: each due chirp is claimed
+// with FOR UPDATE SKIP LOCKED.
+func (cfg *ApiConfig) RunScheduledChirps(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for ctx.Err() == nil {
+			published, err := cfg.publishDueChirp()
+			if err != nil {
+				log.Printf("Error publishing scheduled chirp: %s", err)
+				break
+			}
+			if !published {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDueChirp publishes the chirp that has been due the longest and
+// reports whether there was one. A chirp whose reply or quote target is no
+// longer allowed is marked as failed instead and left for its author.
+func (cfg *ApiConfig) publishDueChirp() (bool, error) {
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	scheduled, err := qtx.ClaimDueScheduledChirp(context.Background())
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	targets, err := resolveChirpTargets(qtx, scheduled.UserID, scheduled.InReplyToID, scheduled.QuoteOfID)
+	if isChirpTargetRefusal(err) {
+		err = qtx.MarkScheduledChirpFailed(context.Background(), database.MarkScheduledChirpFailedParams{
+			ID:      scheduled.ID,
+			Failure: sql.NullString{String: err.Error(), Valid: true},
+		})
+		if err != nil {
+			return false, err
+		}
+		return true, tx.Commit()
+	}
+	if err != nil {
+		return false, err
+	}
+
+	chirp, mentionedIDs, err := insertChirp(qtx, scheduled.UserID, scheduled.Body, scheduled.Visibility, targets)
+	if err != nil {
+		return false, err
+	}
+	_, err = qtx.DeleteScheduledChirp(context.Background(), database.DeleteScheduledChirpParams{
+		ID:     scheduled.ID,
+		UserID: scheduled.UserID,
+	})
+	if err != nil {
+		return false, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	published := toChirp(chirp)
+	err = cfg.decorateChirps(uuid.NullUUID{UUID: chirp.UserID, Valid: true}, &published)
+	if err != nil {
+		log.Printf("Error decorating published chirp %s: %s", chirp.ID, err)
+	}
+	cfg.announceChirp(published, targets, mentionedIDs)
+	return true, nil
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidatePublishAt(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		publishAt time.Time
+		wantErr   error
+	}{
+		{name: "Later today", publishAt: now.Add(time.Hour)},
+		{name: "Other time zone", publishAt: time.Date(2025, 3, 1, 14, 0, 0, 0, time.FixedZone("CET", 3600))},
+		{name: "Exactly a year ahead", publishAt: now.Add(maxScheduleAhead)},
+		{name: "Now", publishAt: now, wantErr: errPublishAtPast},
+		{name: "Past", publishAt: now.Add(-time.Minute), wantErr: errPublishAtPast},
+		{name: "Same wall clock in an earlier zone", publishAt: time.Date(2025, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600)), wantErr: errPublishAtPast},
+		{name: "Too far ahead", publishAt: now.Add(maxScheduleAhead + time.Second), wantErr: errPublishAtTooLate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePublishAt(tt.publishAt, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("validatePublishAt(%v) = %v, want %v", tt.publishAt, err, tt.wantErr)
+			}
+		})
+	}
+}
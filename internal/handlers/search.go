@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+// HandleSearchChirps serves GET /api/chirps/search. `q` accepts bare words,
+// "quoted phrases" and prefix terms ending in `*`; all terms must match.
+// Results are ordered by relevance unless `sort=recent` is passed.
+func (cfg *ApiConfig) HandleSearchChirps(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	tsQuery, err := buildTSQuery(query.Get("q"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	page, err := parsePageRequest(query)
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	var authorID uuid.NullUUID
+	if s := query.Get("author_id"); s != "" {
+		parsedID, err := uuid.Parse(s)
+		if err != nil {
+			res.RespondWithError(w, http.StatusBadRequest, "malformed user id", err)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: parsedID, Valid: true}
+	}
+
+	since, err := parseSearchDate(query.Get("since"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid since date", err)
+		return
+	}
+	until, err := parseSearchDate(query.Get("until"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid until date", err)
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	var chirps []Chirp
+	var nextCursor string
+	if query.Get("sort") == "recent" {
+		rows, err := cfg.DB.SearchChirpsRecent(context.Background(), database.SearchChirpsRecentParams{
+			Query:           tsQuery,
+			AuthorID:        authorID,
+			Since:           since,
+			Until:           until,
+			CursorCreatedAt: page.cursorCreatedAt(),
+			CursorID:        page.cursorID(),
+			ViewerID:        viewerID,
+			PageLimit:       page.fetchLimit(),
+		})
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error searching chirps", err)
+			return
+		}
+		rows, nextCursor = paginate(rows, page, func(row database.SearchChirpsRecentRow) pageCursor {
+			return pageCursor{CreatedAt: row.Chirp.CreatedAt, ID: row.Chirp.ID}
+		})
+		chirps = []Chirp{}
+		for _, row := range rows {
+			chirps = append(chirps, toChirp(row.Chirp))
+		}
+	} else {
+		if page.Cursor != nil && page.Cursor.Rank == nil {
+			res.RespondWithError(w, http.StatusBadRequest, "invalid cursor", nil)
+			return
+		}
+		rows, err := cfg.DB.SearchChirps(context.Background(), database.SearchChirpsParams{
+			Query:           tsQuery,
+			AuthorID:        authorID,
+			Since:           since,
+			Until:           until,
+			CursorRank:      page.cursorRank(),
+			CursorCreatedAt: page.cursorCreatedAt(),
+			CursorID:        page.cursorID(),
+			ViewerID:        viewerID,
+			PageLimit:       page.fetchLimit(),
+		})
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error searching chirps", err)
+			return
+		}
+		rows, nextCursor = paginate(rows, page, func(row database.SearchChirpsRow) pageCursor {
+			return pageCursor{CreatedAt: row.Chirp.CreatedAt, ID: row.Chirp.ID, Rank: &row.Rank}
+		})
+		chirps = []Chirp{}
+		for _, row := range rows {
+			chirps = append(chirps, toChirp(row.Chirp))
+		}
+	}
+
+	err = cfg.decorateChirps(viewerID, chirpPtrs(chirps)...)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error searching chirps", err)
+		return
+	}
+
+	res.RespondWithJSON(w, http.StatusOK, chirpPage{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+// buildTSQuery turns user search input into a to_tsquery expression. Every
+// lexeme is quoted, so input can never inject tsquery operators.
+func buildTSQuery(q string) (string, error) {
+	var terms []string
+
+	rest := strings.TrimSpace(q)
+	for rest != "" {
+		var token string
+		phrase := false
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end == -1 {
+				token, rest = rest[1:], ""
+			} else {
+				token, rest = rest[1:end+1], rest[end+2:]
+			}
+			phrase = true
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end == -1 {
+				token, rest = rest, ""
+			} else {
+				token, rest = rest[:end], rest[end:]
+			}
+		}
+		rest = strings.TrimSpace(rest)
+
+		prefix := !phrase && strings.HasSuffix(token, "*")
+		words := strings.FieldsFunc(token, func(c rune) bool {
+			return !unicode.IsLetter(c) && !unicode.IsNumber(c)
+		})
+		if len(words) == 0 {
+			continue
+		}
+
+		lexemes := make([]string, len(words))
+		for i, word := range words {
+			lexemes[i] = "'" + strings.ToLower(word) + "'"
+		}
+		if prefix {
+			lexemes[len(lexemes)-1] += ":*"
+		}
+		terms = append(terms, strings.Join(lexemes, " <-> "))
+	}
+
+	if len(terms) == 0 {
+		return "", errors.New("search query must contain at least one word")
+	}
+	return strings.Join(terms, " & "), nil
+}
+
+func parseSearchDate(s string) (sql.NullTime, error) {
+	if s == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, s)
+		if err != nil {
+			return sql.NullTime{}, err
+		}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
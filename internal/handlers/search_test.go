@@ -0,0 +1,71 @@
+package handlers
+
+import "testing"
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		q       string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Single word",
+			q:    "Gopher",
+			want: "'gopher'",
+		},
+		{
+			name: "Multiple words",
+			q:    "hello  world",
+			want: "'hello' & 'world'",
+		},
+		{
+			name: "Phrase",
+			q:    `"hello world" again`,
+			want: "'hello' <-> 'world' & 'again'",
+		},
+		{
+			name: "Prefix",
+			q:    "chir*",
+			want: "'chir':*",
+		},
+		{
+			name: "Operators are stripped",
+			q:    "a&b | !c:*",
+			want: "'a' <-> 'b' & 'c':*",
+		},
+		{
+			name: "Unicode words",
+			q:    "café",
+			want: "'café'",
+		},
+		{
+			name: "Unterminated phrase",
+			q:    `"hello world`,
+			want: "'hello' <-> 'world'",
+		},
+		{
+			name:    "Only punctuation",
+			q:       "&& ''",
+			wantErr: true,
+		},
+		{
+			name:    "Empty",
+			q:       "   ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildTSQuery(tt.q)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("buildTSQuery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("buildTSQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
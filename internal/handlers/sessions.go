@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+const (
+	maxDeviceNameLength = 100
+	maxUserAgentLength  = 512
+)
+
+// Session is one login: a refresh token family and the client it was last
+// refreshed from. Its ID is the family ID.
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// clientIP returns the address the request came from. X-Forwarded-For is
+// not trusted: the server is not deployed behind a proxy that sets it.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// truncateRunes shortens s to at most n runes.
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+func (cfg *ApiConfig) HandleGetSessions(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	rows, err := cfg.DB.GetSessionsForUser(context.Background(), userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching sessions", err)
+		return
+	}
+
+	sessions := []Session{}
+	for _, row := range rows {
+		sessions = append(sessions, Session{
+			ID:         row.FamilyID,
+			DeviceName: row.DeviceName,
+			UserAgent:  row.UserAgent,
+			IP:         row.IP,
+			CreatedAt:  row.StartedAt,
+			LastUsedAt: row.LastUsedAt,
+			ExpiresAt:  row.ExpiresAt,
+		})
+	}
+	res.RespondWithJSON(w, http.StatusOK, sessions)
+}
+
+// HandleRevokeSession signs one session out by revoking its refresh
+// tokens and putting the access tokens issued to it on the revocation list.
+func (cfg *ApiConfig) HandleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := uuid.Parse(r.PathValue("sessionID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid session ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error revoking session", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	revoked, err := qtx.RevokeSession(context.Background(), database.RevokeSessionParams{
+		FamilyID: sessionID,
+		UserID:   userID,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error revoking session", err)
+		return
+	}
+	if revoked == 0 {
+		res.RespondWithError(w, http.StatusNotFound, "Session not found", nil)
+		return
+	}
+	revokedTokens, err := qtx.RevokeSessionAccessTokens(context.Background(), sessionID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error revoking session", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error revoking session", err)
+		return
+	}
+	cfg.addRevocations(revokedTokens)
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// HandleRevokeAllSessions signs the user out everywhere, including the
+// session making the request: every refresh token is revoked and every
+// access token issued until now stops working.
+func (cfg *ApiConfig) HandleRevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error revoking sessions", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	err = qtx.RevokeAllSessions(context.Background(), userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error revoking sessions", err)
+		return
+	}
+	// Compared with the iat of access tokens, which is in milliseconds
+	// and taken from this server's clock rather than the database's.
+	validAfter := time.Now().UTC().Truncate(time.Millisecond)
+	err = qtx.SetUserTokensValidAfter(context.Background(), database.SetUserTokensValidAfterParams{
+		ID:               userID,
+		TokensValidAfter: sql.NullTime{Time: validAfter, Valid: true},
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error revoking sessions", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error revoking sessions", err)
+		return
+	}
+	cfg.Revocations.SetValidAfter(userID, validAfter)
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
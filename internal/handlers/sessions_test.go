@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       string
+	}{
+		{name: "IPv4", remoteAddr: "203.0.113.7:52114", want: "203.0.113.7"},
+		{name: "IPv6", remoteAddr: "[2001:db8::1]:443", want: "2001:db8::1"},
+		{name: "No port", remoteAddr: "203.0.113.7", want: "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/login", nil)
+			r.RemoteAddr = tt.remoteAddr
+			r.Header.Set("X-Forwarded-For", "198.51.100.1")
+			if got := clientIP(r); got != tt.want {
+				t.Errorf("clientIP(%q) = %q, want %q", tt.remoteAddr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		n     int
+		want  string
+	}{
+		{name: "Short", input: "Pixel 8", n: 10, want: "Pixel 8"},
+		{name: "Exact", input: "iPad", n: 4, want: "iPad"},
+		{name: "Long", input: "Firefox on Linux", n: 7, want: "Firefox"},
+		{name: "Multibyte", input: "Téléphone", n: 3, want: "Tél"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateRunes(tt.input, tt.n); got != tt.want {
+				t.Errorf("truncateRunes(%q, %d) = %q, want %q", tt.input, tt.n, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+	"github.com/sebmaz93/gocial_server/internal/stream"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+// publishChirpEvent pushes a chirp change to stream subscribers. data is
+// the event payload sent to clients.
+func (cfg *ApiConfig) publishChirpEvent(eventType string, authorID uuid.UUID, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error encoding stream event: %s", err)
+		return
+	}
+	cfg.Stream.Publish(stream.Event{
+		Type:     eventType,
+		AuthorID: authorID,
+		Data:     payload,
+	})
+}
+
+// HandleStream serves GET /api/stream as Server-Sent Events. It pushes
+// chirp.created and chirp.deleted events, optionally only for `author_id`.
+// With an access token, chirps by users blocked either way or muted when
+// the stream starts are left out.
+// Clients resume after a reconnect with the Last-Event-ID header (or the
+// `last_event_id` query parameter) for as long as the hub still holds the
+// events they missed.
+func (cfg *ApiConfig) HandleStream(w http.ResponseWriter, r *http.Request) {
+	if cfg.Stream == nil {
+		res.RespondWithError(w, http.StatusServiceUnavailable, "Streaming is not enabled", nil)
+		return
+	}
+
+	filter := stream.Public
+	if s := r.URL.Query().Get("author_id"); s != "" {
+		authorID, err := uuid.Parse(s)
+		if err != nil {
+			res.RespondWithError(w, http.StatusBadRequest, "malformed user id", err)
+			return
+		}
+		filter = stream.ByAuthor(authorID)
+	}
+	if viewerID := cfg.viewerID(r); viewerID.Valid {
+		hidden, err := cfg.DB.GetHiddenUserIDs(context.Background(), viewerID.UUID)
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error loading blocked and muted users", err)
+			return
+		}
+		filter = stream.ExceptAuthors(filter, hidden)
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	sub, backlog := cfg.Stream.Subscribe(lastEventID, filter)
+	defer cfg.Stream.Unsubscribe(sub)
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, ev := range backlog {
+		writeStreamEvent(w, ev)
+	}
+	if rc.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			writeStreamEvent(w, ev)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, ev stream.Event) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, ev.Data)
+}
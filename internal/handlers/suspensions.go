@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+// HandleSuspendUser suspends an account for moderators holding the admin
+// API key. The user can't log in until unsuspended, every session is
+// signed out and every access token issued so far stops working.
+func (cfg *ApiConfig) HandleSuspendUser(w http.ResponseWriter, r *http.Request) {
+	apiKey, err := auth.GetAPIKey(r.Header)
+	if err != nil || apiKey != cfg.AdminKey {
+		res.RespondWithError(w, http.StatusUnauthorized, "Apikey error", err)
+		return
+	}
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error suspending user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	dbUser, err := qtx.GetUserByIDForUpdate(context.Background(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		res.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error suspending user", err)
+		return
+	}
+	if dbUser.SuspendedAt.Valid {
+		res.RespondWithJSON(w, http.StatusNoContent, nil)
+		return
+	}
+
+	// Like revoking all sessions, this also rejects access tokens that no
+	// session remembers, such as ones replaced by a refresh.
+	validAfter := time.Now().UTC().Truncate(time.Millisecond)
+	err = qtx.SuspendUser(context.Background(), database.SuspendUserParams{
+		ID:               userID,
+		TokensValidAfter: sql.NullTime{Time: validAfter, Valid: true},
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error suspending user", err)
+		return
+	}
+	revokedTokens, err := revokeUserAccessTokens(qtx, userID, uuid.NullUUID{})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error suspending user", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error suspending user", err)
+		return
+	}
+	cfg.addRevocations(revokedTokens)
+	cfg.Revocations.SetValidAfter(userID, validAfter)
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// HandleUnsuspendUser lets a suspended user log in again. Their old
+// sessions stay signed out.
+func (cfg *ApiConfig) HandleUnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	apiKey, err := auth.GetAPIKey(r.Header)
+	if err != nil || apiKey != cfg.AdminKey {
+		res.RespondWithError(w, http.StatusUnauthorized, "Apikey error", err)
+		return
+	}
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	updated, err := cfg.DB.UnsuspendUser(context.Background(), userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error unsuspending user", err)
+		return
+	}
+	if updated == 0 {
+		res.RespondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+const (
+	maxTagLength          = 100
+	defaultTrendingWindow = 24 * time.Hour
+	maxTrendingWindow     = 7 * 24 * time.Hour
+)
+
+type TrendingTag struct {
+	Name string `json:"name"`
+	Uses int64  `json:"uses"`
+}
+
+// extractHashtags returns the distinct, lower-cased hashtags in body in the
+// order they first appear. A hashtag is a '#' that does not follow a word
+// character, followed by letters, digits, marks or underscores including at
+// least one letter.
+func extractHashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]struct{}{}
+
+	runes := []rune(body)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' || (i > 0 && isTagRune(runes[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		hasLetter := false
+		for end < len(runes) && isTagRune(runes[end]) {
+			if unicode.IsLetter(runes[end]) {
+				hasLetter = true
+			}
+			end++
+		}
+
+		tagLength := end - (i + 1)
+		if hasLetter && tagLength <= maxTagLength {
+			tag := normalizeTag(string(runes[i+1 : end]))
+			if _, ok := seen[tag]; !ok {
+				seen[tag] = struct{}{}
+				tags = append(tags, tag)
+			}
+		}
+		i = end - 1
+	}
+	return tags
+}
+
+func isTagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r)
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+// setChirpTags makes the stored tags of a chirp match the hashtags in body.
+// Tags the chirp already had keep their original timestamp so edits don't
+// inflate trending counts.
+func setChirpTags(q *database.Queries, chirp database.Chirp) error {
+	tags := extractHashtags(chirp.Body)
+
+	err := q.RemoveChirpTagsExcept(context.Background(), database.RemoveChirpTagsExceptParams{
+		ChirpID: chirp.ID,
+		Names:   tags,
+	})
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+
+	err = q.CreateTags(context.Background(), tags)
+	if err != nil {
+		return err
+	}
+	return q.AddChirpTags(context.Background(), database.AddChirpTagsParams{
+		ChirpID: chirp.ID,
+		Names:   tags,
+	})
+}
+
+// HandleGetChirpsByTag lists chirps carrying a hashtag, newest first. The tag
+// may be given with or without its leading '#'.
+func (cfg *ApiConfig) HandleGetChirpsByTag(w http.ResponseWriter, r *http.Request) {
+	tag := normalizeTag(r.PathValue("tag"))
+	if tag == "" {
+		res.RespondWithError(w, http.StatusBadRequest, "Tag missing", nil)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	dbChirps, err := cfg.DB.GetChirpsByTag(context.Background(), database.GetChirpsByTagParams{
+		Name:            tag,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		ViewerID:        viewerID,
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching chirps", err)
+		return
+	}
+
+	dbChirps, nextCursor := paginate(dbChirps, page, func(c database.Chirp) pageCursor {
+		return pageCursor{CreatedAt: c.CreatedAt, ID: c.ID}
+	})
+	chirps := toChirps(dbChirps)
+	err = cfg.decorateChirps(viewerID, chirpPtrs(chirps)...)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching chirps", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusOK, chirpPage{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+// HandleGetTrendingTags returns the most used tags over the trailing
+// `window` (a Go duration such as "6h", default 24h, at most 7 days).
+func (cfg *ApiConfig) HandleGetTrendingTags(w http.ResponseWriter, r *http.Request) {
+	window := defaultTrendingWindow
+	if s := r.URL.Query().Get("window"); s != "" {
+		parsed, err := time.ParseDuration(s)
+		if err != nil || parsed <= 0 {
+			res.RespondWithError(w, http.StatusBadRequest, "Invalid window", err)
+			return
+		}
+		window = min(parsed, maxTrendingWindow)
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.DB.GetTrendingTags(context.Background(), database.GetTrendingTagsParams{
+		Since:     time.Now().UTC().Add(-window),
+		PageLimit: page.Limit,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching trending tags", err)
+		return
+	}
+
+	tags := []TrendingTag{}
+	for _, row := range rows {
+		tags = append(tags, TrendingTag{
+			Name: row.Name,
+			Uses: row.Uses,
+		})
+	}
+	res.RespondWithJSON(w, http.StatusOK, tags)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "No tags",
+			body: "just a chirp",
+			want: []string{},
+		},
+		{
+			name: "Simple tags",
+			body: "learning #Go and #sql_c today",
+			want: []string{"go", "sql_c"},
+		},
+		{
+			name: "Case insensitive duplicates",
+			body: "#Gopher #gopher #GOPHER",
+			want: []string{"gopher"},
+		},
+		{
+			name: "Unicode tags",
+			body: "#Café au lait #東京 #Ünïcödé",
+			want: []string{"café", "東京", "ünïcödé"},
+		},
+		{
+			name: "Punctuation ends a tag",
+			body: "so good #pizza! (#pasta)",
+			want: []string{"pizza", "pasta"},
+		},
+		{
+			name: "Numbers only is not a tag",
+			body: "we're #1 #2024",
+			want: []string{},
+		},
+		{
+			name: "Hash inside a word is not a tag",
+			body: "C# and issue#42 but #rust",
+			want: []string{"rust"},
+		},
+		{
+			name: "Lone hash",
+			body: "# #",
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractHashtags(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractHashtags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+// HandleGetTimeline returns chirps and rechirps from the accounts the caller
+// follows, newest first.
+func (cfg *ApiConfig) HandleGetTimeline(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.DB.GetTimeline(context.Background(), database.GetTimelineParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching timeline", err)
+		return
+	}
+
+	rows, nextCursor := paginate(rows, page, func(row database.GetTimelineRow) pageCursor {
+		return pageCursor{CreatedAt: row.ItemCreatedAt, ID: row.ItemID}
+	})
+	chirps := []Chirp{}
+	for _, row := range rows {
+		chirps = append(chirps, feedItem(row.Chirp, row.ItemID, row.ItemCreatedAt, row.RechirpedBy))
+	}
+	err = cfg.decorateChirps(uuid.NullUUID{UUID: userID, Valid: true}, chirpPtrs(chirps)...)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error fetching timeline", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusOK, chirpPage{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
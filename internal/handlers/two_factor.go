@@ -0,0 +1,504 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+)
+
+const (
+	totpIssuer            = "Chirpy"
+	recoveryCodeCount     = 10
+	mfaChallengeExpiresIn = 5 * time.Minute
+	// maxMFAAttempts is how many wrong codes a challenge takes before it
+	// is thrown away and the password has to be entered again.
+	maxMFAAttempts = 5
+
+	// After maxMFAFailures wrong codes in a row, whichever endpoint they
+	// were sent to, the user is locked out of second-factor checks for
+	// mfaLockoutBase, doubling with every further failure up to
+	// maxMFALockout.
+	maxMFAFailures = 5
+	mfaLockoutBase = time.Minute
+	maxMFALockout  = time.Hour
+)
+
+// mfaLockout returns how long a user is locked out after failures wrong
+// codes in a row.
+func mfaLockout(failures int32) time.Duration {
+	if failures < maxMFAFailures {
+		return 0
+	}
+	lockout := mfaLockoutBase
+	for i := int32(maxMFAFailures); i < failures && lockout < maxMFALockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, maxMFALockout)
+}
+
+// checkSecondFactor reports whether code is a current TOTP code for user,
+// or recoveryCode one of their unused recovery codes, and uses it up.
+// user must have been locked with GetUserByIDForUpdate in q's transaction.
+func checkSecondFactor(q *database.Queries, user database.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		step, ok := auth.ValidateTOTP(user.TOTPSecret.String, code, time.Now(), user.TOTPLastStep)
+		if !ok {
+			return false, nil
+		}
+		err := q.SetUserTOTPLastStep(context.Background(), database.SetUserTOTPLastStepParams{
+			ID:           user.ID,
+			TOTPLastStep: step,
+		})
+		return err == nil, err
+	}
+
+	// Anything that can't be a recovery code is turned down without
+	// paying for a hash per stored code.
+	recoveryCode = auth.NormalizeRecoveryCode(recoveryCode)
+	if !auth.IsRecoveryCode(recoveryCode) {
+		return false, nil
+	}
+	codes, err := q.GetUnusedRecoveryCodes(context.Background(), user.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range codes {
+		match, err := auth.CheckPasswordHash(recoveryCode, c.CodeHash)
+		if err != nil || !match {
+			continue
+		}
+		used, err := q.UseRecoveryCode(context.Background(), c.ID)
+		return used == 1, err
+	}
+	return false, nil
+}
+
+// verifySecondFactor is checkSecondFactor behind the per-user lockout. A
+// locked out user's code isn't checked at all; the returned duration says
+// how long until they can try again. Failures are recorded in q's
+// transaction, which the caller commits whether or not the code was right.
+func verifySecondFactor(q *database.Queries, user database.User, code, recoveryCode string) (bool, time.Duration, error) {
+	now := time.Now().UTC()
+	if user.MFALockedUntil.Valid && now.Before(user.MFALockedUntil.Time) {
+		return false, user.MFALockedUntil.Time.Sub(now), nil
+	}
+
+	ok, err := checkSecondFactor(q, user, code, recoveryCode)
+	if err != nil {
+		return false, 0, err
+	}
+	if ok {
+		if user.MFAFailedAttempts > 0 {
+			err = q.ResetMFAFailures(context.Background(), user.ID)
+		}
+		return err == nil, 0, err
+	}
+
+	failures := user.MFAFailedAttempts + 1
+	lockedUntil := sql.NullTime{}
+	if lockout := mfaLockout(failures); lockout > 0 {
+		lockedUntil = sql.NullTime{Time: now.Add(lockout), Valid: true}
+	}
+	err = q.RecordMFAFailure(context.Background(), database.RecordMFAFailureParams{
+		ID:                user.ID,
+		MFAFailedAttempts: failures,
+		MFALockedUntil:    lockedUntil,
+	})
+	return false, 0, err
+}
+
+// respondWithMFALockout tells a locked out user when to try again.
+func respondWithMFALockout(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	res.RespondWithError(w, http.StatusTooManyRequests, "Too many wrong codes, try again later", nil)
+}
+
+// replaceRecoveryCodes invalidates the user's recovery codes and returns a
+// new set. Only their hashes are stored, so they are shown once.
+func replaceRecoveryCodes(q *database.Queries, userID uuid.UUID) ([]string, error) {
+	err := q.DeleteRecoveryCodes(context.Background(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := auth.MakeRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := auth.HashPassword(auth.NormalizeRecoveryCode(code))
+		if err != nil {
+			return nil, err
+		}
+		err = q.CreateRecoveryCode(context.Background(), database.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: hash,
+		})
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// startMFAChallenge answers a correct password from a user with two-factor
+// authentication: instead of tokens they get a short-lived challenge token
+// to present with a code.
+func (cfg *ApiConfig) startMFAChallenge(w http.ResponseWriter, dbUser database.User, deviceName string) {
+	err := cfg.DB.DeleteExpiredMFAChallenges(context.Background())
+	if err != nil {
+		log.Printf("Error deleting expired MFA challenges: %s", err)
+	}
+
+	challengeToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating MFA challenge", err)
+		return
+	}
+	expiresAt := time.Now().UTC().Add(mfaChallengeExpiresIn)
+	err = cfg.DB.CreateMFAChallenge(context.Background(), database.CreateMFAChallengeParams{
+		Token:      challengeToken,
+		UserID:     dbUser.ID,
+		DeviceName: deviceName,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating MFA challenge", err)
+		return
+	}
+
+	type response struct {
+		MFARequired bool      `json:"mfa_required"`
+		MFAToken    string    `json:"mfa_token"`
+		ExpiresAt   time.Time `json:"expires_at"`
+	}
+	res.RespondWithJSON(w, http.StatusOK, response{
+		MFARequired: true,
+		MFAToken:    challengeToken,
+		ExpiresAt:   expiresAt,
+	})
+}
+
+// HandleLoginMFA completes a login started by HandleLogin with a TOTP code
+// or a recovery code, and responds like a login without 2FA.
+func (cfg *ApiConfig) HandleLoginMFA(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	err := decoder.Decode(&params)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error verifying code", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	challenge, err := qtx.GetMFAChallengeForUpdate(context.Background(), params.MFAToken)
+	if errors.Is(err, sql.ErrNoRows) {
+		res.RespondWithError(w, http.StatusUnauthorized, "MFA token not found or expired", err)
+		return
+	}
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error verifying code", err)
+		return
+	}
+	dbUser, err := qtx.GetUserByIDForUpdate(context.Background(), challenge.UserID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error verifying code", err)
+		return
+	}
+	// Suspended since the password was checked.
+	if dbUser.SuspendedAt.Valid {
+		res.RespondWithError(w, http.StatusForbidden, "Account suspended", nil)
+		return
+	}
+
+	ok := false
+	if dbUser.TOTPEnabled {
+		var retryAfter time.Duration
+		ok, retryAfter, err = verifySecondFactor(qtx, dbUser, params.Code, params.RecoveryCode)
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error verifying code", err)
+			return
+		}
+		if retryAfter > 0 {
+			respondWithMFALockout(w, retryAfter)
+			return
+		}
+	}
+
+	// A challenge is used up by a correct code or by too many wrong ones.
+	if ok || challenge.Attempts+1 >= maxMFAAttempts {
+		err = qtx.DeleteMFAChallenge(context.Background(), challenge.Token)
+	} else {
+		err = qtx.IncrementMFAChallengeAttempts(context.Background(), challenge.Token)
+	}
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error verifying code", err)
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error verifying code", err)
+		return
+	}
+	if !ok {
+		res.RespondWithError(w, http.StatusUnauthorized, "Invalid code", nil)
+		return
+	}
+
+	cfg.startSession(w, r, dbUser, challenge.DeviceName)
+}
+
+// HandleEnrollTOTP starts two-factor enrolment with a new secret for the
+// user's authenticator app. Nothing changes at login until the secret is
+// confirmed with HandleConfirmTOTP; enrolling again replaces it.
+func (cfg *ApiConfig) HandleEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	dbUser, err := cfg.DB.GetUserByID(context.Background(), userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error enrolling two-factor authentication", err)
+		return
+	}
+	secret, uri, err := auth.GenerateTOTPKey(totpIssuer, dbUser.Email)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error enrolling two-factor authentication", err)
+		return
+	}
+
+	updated, err := cfg.DB.SetUserTOTPSecret(context.Background(), database.SetUserTOTPSecretParams{
+		ID:         userID,
+		TOTPSecret: sql.NullString{String: secret, Valid: true},
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error enrolling two-factor authentication", err)
+		return
+	}
+	if updated == 0 {
+		res.RespondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+
+	type response struct {
+		Secret     string `json:"secret"`
+		OtpauthURI string `json:"otpauth_uri"`
+	}
+	res.RespondWithJSON(w, http.StatusOK, response{
+		Secret:     secret,
+		OtpauthURI: uri,
+	})
+}
+
+// HandleConfirmTOTP turns two-factor authentication on once the user shows
+// their authenticator works by sending a first code. The response holds
+// the recovery codes, which can't be retrieved again. Wrong codes count
+// towards the same lockout as logins.
+func (cfg *ApiConfig) HandleConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	type parameters struct {
+		Code string `json:"code"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	err = decoder.Decode(&params)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error enabling two-factor authentication", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	dbUser, err := qtx.GetUserByIDForUpdate(context.Background(), userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error enabling two-factor authentication", err)
+		return
+	}
+	if dbUser.TOTPEnabled {
+		res.RespondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+	if !dbUser.TOTPSecret.Valid {
+		res.RespondWithError(w, http.StatusBadRequest, "Two-factor enrolment has not been started", nil)
+		return
+	}
+
+	// Only a TOTP code for the pending secret is accepted.
+	ok, retryAfter, err := verifySecondFactor(qtx, dbUser, params.Code, "")
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error enabling two-factor authentication", err)
+		return
+	}
+	if retryAfter > 0 {
+		respondWithMFALockout(w, retryAfter)
+		return
+	}
+	if !ok {
+		// Keep the recorded failure.
+		err = tx.Commit()
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error enabling two-factor authentication", err)
+			return
+		}
+		res.RespondWithError(w, http.StatusForbidden, "Invalid code", nil)
+		return
+	}
+	err = qtx.EnableUserTOTP(context.Background(), userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error enabling two-factor authentication", err)
+		return
+	}
+	recoveryCodes, err := replaceRecoveryCodes(qtx, userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error enabling two-factor authentication", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error enabling two-factor authentication", err)
+		return
+	}
+
+	type response struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	res.RespondWithJSON(w, http.StatusOK, response{
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+// HandleDisableTOTP turns two-factor authentication off. It takes a TOTP
+// code or a recovery code, so a stolen access token alone can't do it;
+// wrong codes count towards the same lockout as logins.
+func (cfg *ApiConfig) HandleDisableTOTP(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	type parameters struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	params := parameters{}
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	err = decoder.Decode(&params)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error disabling two-factor authentication", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	dbUser, err := qtx.GetUserByIDForUpdate(context.Background(), userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error disabling two-factor authentication", err)
+		return
+	}
+	if !dbUser.TOTPEnabled {
+		res.RespondWithError(w, http.StatusConflict, "Two-factor authentication is not enabled", nil)
+		return
+	}
+
+	ok, retryAfter, err := verifySecondFactor(qtx, dbUser, params.Code, params.RecoveryCode)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error disabling two-factor authentication", err)
+		return
+	}
+	if retryAfter > 0 {
+		respondWithMFALockout(w, retryAfter)
+		return
+	}
+	if !ok {
+		// Keep the recorded failure.
+		err = tx.Commit()
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error disabling two-factor authentication", err)
+			return
+		}
+		res.RespondWithError(w, http.StatusForbidden, "Invalid code", nil)
+		return
+	}
+
+	err = qtx.DisableUserTOTP(context.Background(), userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error disabling two-factor authentication", err)
+		return
+	}
+	err = qtx.DeleteRecoveryCodes(context.Background(), userID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error disabling two-factor authentication", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error disabling two-factor authentication", err)
+		return
+	}
+	res.RespondWithJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMFALockout(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int32
+		want     time.Duration
+	}{
+		{name: "None", failures: 0, want: 0},
+		{name: "Below limit", failures: 4, want: 0},
+		{name: "At limit", failures: 5, want: time.Minute},
+		{name: "Doubles", failures: 7, want: 4 * time.Minute},
+		{name: "Capped", failures: 12, want: time.Hour},
+		{name: "Far past cap", failures: 1000, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mfaLockout(tt.failures); got != tt.want {
+				t.Errorf("mfaLockout(%d) = %v, want %v", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,31 +2,114 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/sebmaz93/gocial_server/internal/auth"
 	"github.com/sebmaz93/gocial_server/internal/database"
+	"github.com/sebmaz93/gocial_server/internal/notifications"
 	res "github.com/sebmaz93/gocial_server/internal/response"
 )
 
 type User struct {
-	ID         uuid.UUID `json:"id"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	Email      string    `json:"email"`
-	Token      string    `json:"token"`
-	IsChirpRed bool      `json:"is_chirpy_red"`
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Email       string    `json:"email"`
+	Handle      string    `json:"handle"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarURL   string    `json:"avatar_url"`
+	Token       string    `json:"token"`
+	IsChirpRed  bool      `json:"is_chirpy_red"`
+	IsPrivate   bool      `json:"is_private"`
 }
 
-const defaultExpiresIn = time.Hour * 1
+const (
+	defaultExpiresIn      = time.Hour * 1
+	refreshTokenExpiresIn = time.Hour * 24 * 60
+	minHandleLength       = 3
+	maxHandleLength       = 30
+)
+
+var errInvalidHandle = errors.New("handle must be 3-30 letters, digits or underscores")
+
+// validHandle reports whether handle can be claimed by a user and mentioned
+// as @handle in a chirp.
+func validHandle(handle string) bool {
+	if len(handle) < minHandleLength || len(handle) > maxHandleLength {
+		return false
+	}
+	for i := 0; i < len(handle); i++ {
+		if !isHandleByte(handle[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHandleByte(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// parseHandle validates an optional handle from a request body.
+func parseHandle(handle *string) (sql.NullString, error) {
+	if handle == nil {
+		return sql.NullString{}, nil
+	}
+	if !validHandle(*handle) {
+		return sql.NullString{}, errInvalidHandle
+	}
+	return sql.NullString{String: *handle, Valid: true}, nil
+}
+
+// Unique constraints on users, as named by Postgres.
+const (
+	usersEmailConstraint  = "users_email_key"
+	usersHandleConstraint = "users_handle_lower_idx"
+)
+
+// uniqueViolation reports whether err is a unique violation and, if so,
+// which constraint was violated.
+func uniqueViolation(err error) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return pqErr.Constraint, true
+	}
+	return "", false
+}
+
+func isUniqueViolation(err error) bool {
+	_, ok := uniqueViolation(err)
+	return ok
+}
+
+// userConflictMessage says which unique field of a user was already taken.
+func userConflictMessage(constraint string) string {
+	switch constraint {
+	case usersEmailConstraint:
+		return "Email already registered"
+	case usersHandleConstraint:
+		return "Handle already taken"
+	default:
+		return "User already exists"
+	}
+}
 
 func (cfg *ApiConfig) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email       string  `json:"email"`
+		Password    string  `json:"password"`
+		Handle      *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		AvatarURL   *string `json:"avatar_url"`
 	}
 	type responseBody struct {
 		User
@@ -41,6 +124,17 @@ func (cfg *ApiConfig) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	handle, err := parseHandle(params.Handle)
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	profile, err := parseProfileFields(params.DisplayName, params.Bio, params.AvatarURL)
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
 	hashedPass, err := auth.HashPassword(params.Password)
 	if err != nil {
 		res.RespondWithError(w, http.StatusInternalServerError, "Error creating user", err)
@@ -49,7 +143,15 @@ func (cfg *ApiConfig) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 	user, err := cfg.DB.CreateUser(context.Background(), database.CreateUserParams{
 		Email:          params.Email,
 		HashedPassword: hashedPass,
+		Handle:         handle,
+		DisplayName:    profile.DisplayName,
+		Bio:            profile.Bio,
+		AvatarURL:      profile.AvatarURL,
 	})
+	if constraint, ok := uniqueViolation(err); ok {
+		res.RespondWithError(w, http.StatusConflict, userConflictMessage(constraint), err)
+		return
+	}
 	if err != nil {
 		res.RespondWithError(w, http.StatusInternalServerError, "Error creating user", err)
 		return
@@ -57,25 +159,29 @@ func (cfg *ApiConfig) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 
 	res.RespondWithJSON(w, http.StatusCreated, responseBody{
 		User: User{
-			ID:         user.ID,
-			CreatedAt:  user.CreatedAt,
-			UpdatedAt:  user.UpdatedAt,
-			Email:      user.Email,
-			IsChirpRed: user.IsChirpyRed,
+			ID:          user.ID,
+			CreatedAt:   user.CreatedAt,
+			UpdatedAt:   user.UpdatedAt,
+			Email:       user.Email,
+			Handle:      user.Handle.String,
+			DisplayName: user.DisplayName.String,
+			Bio:         user.Bio.String,
+			AvatarURL:   user.AvatarURL.String,
+			IsChirpRed:  user.IsChirpyRed,
+			IsPrivate:   user.IsPrivate,
 		},
 	})
 }
 
+// HandleLogin checks a user's password. Users with two-factor
+// authentication get an MFA challenge to complete at POST /api/login/mfa;
+// everyone else gets their tokens straight away.
 func (cfg *ApiConfig) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
-	}
-
-	type response struct {
-		User
-		Token        string `json:"token"`
-		RefreshToken string `json:"refresh_token"`
+		// DeviceName labels the session in GET /api/sessions.
+		DeviceName string `json:"device_name"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -98,17 +204,50 @@ func (cfg *ApiConfig) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		res.RespondWithError(w, http.StatusUnauthorized, "Incorrect email or password", nil)
 		return
 	}
+	if dbUser.SuspendedAt.Valid {
+		res.RespondWithError(w, http.StatusForbidden, "Account suspended", nil)
+		return
+	}
+
+	deviceName := truncateRunes(params.DeviceName, maxDeviceNameLength)
+	if dbUser.TOTPEnabled {
+		cfg.startMFAChallenge(w, dbUser, deviceName)
+		return
+	}
+	cfg.startSession(w, r, dbUser, deviceName)
+}
 
-	token, err := auth.MakeJWT(dbUser.ID, cfg.JWTSecret, defaultExpiresIn)
+// startSession completes a login: it issues an access token and the first
+// refresh token of a new session for dbUser and responds with them.
+func (cfg *ApiConfig) startSession(w http.ResponseWriter, r *http.Request, dbUser database.User, deviceName string) {
+	type response struct {
+		User
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	token, claims, err := auth.MakeJWT(dbUser.ID, cfg.JWTKeys, defaultExpiresIn)
 	if err != nil {
 		res.RespondWithError(w, http.StatusInternalServerError, "Error creating JWT", err)
 		return
 	}
 	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating refresh JWT", err)
+		return
+	}
+	// Each login starts a new token family.
 	err = cfg.DB.CreateRefreshToken(context.Background(), database.CreateRefreshTokenParams{
-		Token:     refreshToken,
-		UserID:    dbUser.ID,
-		ExpiresAt: time.Now().UTC().Add(time.Hour * 24 * 60),
+		Token:      refreshToken,
+		UserID:     dbUser.ID,
+		ExpiresAt:  time.Now().UTC().Add(refreshTokenExpiresIn),
+		FamilyID:   uuid.New(),
+		DeviceName: deviceName,
+		UserAgent:  truncateRunes(r.UserAgent(), maxUserAgentLength),
+		IP:         clientIP(r),
+		// Remembered so signing the session out also revokes the token.
+		AccessTokenJTI:       sql.NullString{String: claims.ID, Valid: true},
+		AccessTokenExpiresAt: sql.NullTime{Time: claims.ExpiresAt, Valid: true},
 	})
 	if err != nil {
 		res.RespondWithError(w, http.StatusInternalServerError, "Error creating refresh JWT", err)
@@ -117,65 +256,154 @@ func (cfg *ApiConfig) HandleLogin(w http.ResponseWriter, r *http.Request) {
 
 	res.RespondWithJSON(w, http.StatusOK, response{
 		User: User{
-			ID:         dbUser.ID,
-			CreatedAt:  dbUser.CreatedAt,
-			UpdatedAt:  dbUser.UpdatedAt,
-			Email:      dbUser.Email,
-			IsChirpRed: dbUser.IsChirpyRed,
+			ID:          dbUser.ID,
+			CreatedAt:   dbUser.CreatedAt,
+			UpdatedAt:   dbUser.UpdatedAt,
+			Email:       dbUser.Email,
+			Handle:      dbUser.Handle.String,
+			DisplayName: dbUser.DisplayName.String,
+			Bio:         dbUser.Bio.String,
+			AvatarURL:   dbUser.AvatarURL.String,
+			IsChirpRed:  dbUser.IsChirpyRed,
+			IsPrivate:   dbUser.IsPrivate,
 		},
 		Token:        token,
 		RefreshToken: refreshToken,
 	})
 }
 
+// HandleRefreshToken trades a refresh token for a new access token and a
+// new refresh token; the old refresh token is revoked. Presenting a
+// refresh token that was already rotated means it has leaked or been
+// replayed, so every token in its family is revoked and the holder has to
+// log in again, and the access tokens issued to the family are revoked.
+// Tokens revoked by logging out or signing a session out are just
+// rejected.
 func (cfg *ApiConfig) HandleRefreshToken(w http.ResponseWriter, r *http.Request) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
 		res.RespondWithError(w, http.StatusUnauthorized, "Error reading refresh JWT", err)
 		return
 	}
-	// TODO : get user from DB not from token
-	dbToken, err := cfg.DB.GetRefreshToken(context.Background(), token)
+
+	newRefreshToken, err := auth.MakeRefreshToken()
 	if err != nil {
-		res.RespondWithError(w, http.StatusUnauthorized, "Error token not found or expired", err)
+		res.RespondWithError(w, http.StatusInternalServerError, "Error creating refresh JWT", err)
 		return
 	}
-	i := time.Now().Compare(dbToken.ExpiresAt)
-	if i >= 0 || dbToken.RevokedAt.Valid {
-		res.RespondWithError(w, http.StatusUnauthorized, "Error token not found or expired", err)
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error refreshing token", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	// Rotation is a single statement that only matches a live token, so
+	// two requests racing with the same token can't both succeed.
+	rotated, err := qtx.RotateRefreshToken(context.Background(), database.RotateRefreshTokenParams{
+		OldToken:  token,
+		NewToken:  newRefreshToken,
+		ExpiresAt: time.Now().UTC().Add(refreshTokenExpiresIn),
+		UserAgent: truncateRunes(r.UserAgent(), maxUserAgentLength),
+		IP:        clientIP(r),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		dbToken, err := qtx.GetRefreshToken(context.Background(), token)
+		if err == nil && dbToken.RotatedAt.Valid {
+			var revokedTokens []database.RevokedAccessToken
+			revoked, err := qtx.RevokeRefreshTokenFamily(context.Background(), dbToken.FamilyID)
+			if err == nil {
+				revokedTokens, err = qtx.RevokeSessionAccessTokens(context.Background(), dbToken.FamilyID)
+			}
+			if err == nil {
+				err = tx.Commit()
+			}
+			if err != nil {
+				res.RespondWithError(w, http.StatusInternalServerError, "Error refreshing token", err)
+				return
+			}
+			cfg.addRevocations(revokedTokens)
+			log.Printf("Security: rotated refresh token reused for user %s, revoked %d tokens in family %s", dbToken.UserID, revoked, dbToken.FamilyID)
+		}
+		res.RespondWithError(w, http.StatusUnauthorized, "Error token not found or expired", nil)
+		return
+	}
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error refreshing token", err)
 		return
 	}
 
-	newToken, err := auth.MakeJWT(dbToken.UserID, cfg.JWTSecret, defaultExpiresIn)
+	newToken, claims, err := auth.MakeJWT(rotated.UserID, cfg.JWTKeys, defaultExpiresIn)
 	if err != nil {
 		res.RespondWithError(w, http.StatusInternalServerError, "Error creating JWT", err)
 		return
 	}
+	err = qtx.SetRefreshTokenAccessToken(context.Background(), database.SetRefreshTokenAccessTokenParams{
+		Token:                rotated.Token,
+		AccessTokenJTI:       sql.NullString{String: claims.ID, Valid: true},
+		AccessTokenExpiresAt: sql.NullTime{Time: claims.ExpiresAt, Valid: true},
+	})
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error refreshing token", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error refreshing token", err)
+		return
+	}
 	type response struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 	res.RespondWithJSON(w, http.StatusOK, response{
-		Token: newToken,
+		Token:        newToken,
+		RefreshToken: rotated.Token,
 	})
 }
 
+// HandleRevokeToken logs out: the refresh token is revoked along with the
+// access tokens issued to its session.
 func (cfg *ApiConfig) HandleRevokeToken(w http.ResponseWriter, r *http.Request) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
 		res.RespondWithError(w, http.StatusUnauthorized, "Error reading refresh JWT", err)
 		return
 	}
-	_, err = cfg.DB.GetRefreshToken(context.Background(), token)
+	dbToken, err := cfg.DB.GetRefreshToken(context.Background(), token)
 	if err != nil {
 		res.RespondWithError(w, http.StatusUnauthorized, "Error token not found or expired", err)
 		return
 	}
 
-	err = cfg.DB.RevokeToken(context.Background(), token)
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
 	if err != nil {
 		res.RespondWithError(w, http.StatusInternalServerError, "Error revoking token", err)
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	err = qtx.RevokeToken(context.Background(), token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error revoking token", err)
+		return
+	}
+	revokedTokens, err := qtx.RevokeSessionAccessTokens(context.Background(), dbToken.FamilyID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error revoking token", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error revoking token", err)
+		return
+	}
+	cfg.addRevocations(revokedTokens)
 	res.RespondWithJSON(w, http.StatusNoContent, nil)
 }
 
@@ -186,15 +414,21 @@ func (cfg *ApiConfig) HandleUpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	uuid, err := auth.ValidateJWT(token, cfg.JWTSecret)
+	claims, err := cfg.parseAccessToken(token)
 	if err != nil {
 		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
 		return
 	}
 
+	// Every field is optional; fields left out keep their current value.
 	type parameters struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email       *string `json:"email"`
+		Password    *string `json:"password"`
+		Handle      *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		AvatarURL   *string `json:"avatar_url"`
+		IsPrivate   *bool   `json:"is_private"`
 	}
 
 	params := parameters{}
@@ -206,27 +440,133 @@ func (cfg *ApiConfig) HandleUpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(params.Password)
+	var email sql.NullString
+	if params.Email != nil {
+		if *params.Email == "" {
+			res.RespondWithError(w, http.StatusBadRequest, "Email can't be empty", nil)
+			return
+		}
+		email = sql.NullString{String: *params.Email, Valid: true}
+	}
+
+	handle, err := parseHandle(params.Handle)
+	if err != nil {
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	profile, err := parseProfileFields(params.DisplayName, params.Bio, params.AvatarURL)
 	if err != nil {
-		res.RespondWithError(w, http.StatusInternalServerError, "Hashing password error", err)
+		res.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	updatedUser, err := cfg.DB.UpdateUser(context.Background(), database.UpdateUserParams{
-		ID:             uuid,
-		Email:          params.Email,
+	var hashedPassword sql.NullString
+	if params.Password != nil {
+		if *params.Password == "" {
+			res.RespondWithError(w, http.StatusBadRequest, "Password can't be empty", nil)
+			return
+		}
+		hash, err := auth.HashPassword(*params.Password)
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Hashing password error", err)
+			return
+		}
+		hashedPassword = sql.NullString{String: hash, Valid: true}
+	}
+
+	var isPrivate sql.NullBool
+	if params.IsPrivate != nil {
+		isPrivate = sql.NullBool{Bool: *params.IsPrivate, Valid: true}
+	}
+
+	tx, err := cfg.DBConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating user info", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	currentUser, err := qtx.GetUserByIDForUpdate(context.Background(), claims.UserID)
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating user info", err)
+		return
+	}
+	passwordChanged := false
+	if params.Password != nil {
+		samePassword, err := auth.CheckPasswordHash(*params.Password, currentUser.HashedPassword)
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error updating user info", err)
+			return
+		}
+		passwordChanged = !samePassword
+	}
+
+	updatedUser, err := qtx.UpdateUser(context.Background(), database.UpdateUserParams{
+		ID:             claims.UserID,
+		Email:          email,
 		HashedPassword: hashedPassword,
+		Handle:         handle,
+		DisplayName:    profile.DisplayName,
+		Bio:            profile.Bio,
+		AvatarURL:      profile.AvatarURL,
+		IsPrivate:      isPrivate,
 	})
+	if constraint, ok := uniqueViolation(err); ok {
+		res.RespondWithError(w, http.StatusConflict, userConflictMessage(constraint), err)
+		return
+	}
+	if err != nil {
+		res.RespondWithError(w, http.StatusInternalServerError, "Error updating user info", err)
+		return
+	}
+	// Going public lets everyone follow, including those still waiting.
+	if !updatedUser.IsPrivate {
+		err = qtx.AcceptAllFollowRequests(context.Background(), updatedUser.ID)
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error updating user info", err)
+			return
+		}
+	}
+
+	// A new password signs out every other session; the one making the
+	// change stays signed in.
+	var revokedTokens []database.RevokedAccessToken
+	if passwordChanged {
+		keepFamily, err := qtx.GetFamilyIDByAccessTokenJTI(context.Background(), sql.NullString{String: claims.ID, Valid: true})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error updating user info", err)
+			return
+		}
+		revokedTokens, err = revokeUserAccessTokens(qtx, claims.UserID, uuid.NullUUID{UUID: keepFamily, Valid: err == nil})
+		if err != nil {
+			res.RespondWithError(w, http.StatusInternalServerError, "Error updating user info", err)
+			return
+		}
+	}
+
+	err = tx.Commit()
 	if err != nil {
 		res.RespondWithError(w, http.StatusInternalServerError, "Error updating user info", err)
 		return
 	}
+	cfg.addRevocations(revokedTokens)
 	type response struct {
-		Email string `json:"email"`
+		Email       string `json:"email"`
+		Handle      string `json:"handle"`
+		DisplayName string `json:"display_name"`
+		Bio         string `json:"bio"`
+		AvatarURL   string `json:"avatar_url"`
+		IsPrivate   bool   `json:"is_private"`
 	}
 
 	res.RespondWithJSON(w, http.StatusOK, response{
-		Email: updatedUser.Email,
+		Email:       updatedUser.Email,
+		Handle:      updatedUser.Handle.String,
+		DisplayName: updatedUser.DisplayName.String,
+		Bio:         updatedUser.Bio.String,
+		AvatarURL:   updatedUser.AvatarURL.String,
+		IsPrivate:   updatedUser.IsPrivate,
 	})
 }
 
@@ -268,5 +608,6 @@ func (cfg *ApiConfig) HandlePolkaHook(w http.ResponseWriter, r *http.Request) {
 		res.RespondWithError(w, http.StatusNotFound, "Error updating user info", err)
 		return
 	}
+	cfg.Notifier.Notify(parsedUserID, notifications.TypeRedUpgrade, uuid.Nil, uuid.Nil)
 	res.RespondWithJSON(w, http.StatusNoContent, nil)
 }
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	"github.com/sebmaz93/gocial_server/internal/database"
+)
+
+// viewerID returns the authenticated user behind an optional bearer token.
+// Public endpoints use it to personalise responses; a missing or invalid
+// token simply means an anonymous viewer.
+func (cfg *ApiConfig) viewerID(r *http.Request) uuid.NullUUID {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	userID, err := cfg.validateAccessToken(token)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}
+}
+
+// decorateChirps embeds quoted originals and mentions and fills in the
+// per-viewer fields of chirps, using one query per concern rather than one
+// per chirp.
+func (cfg *ApiConfig) decorateChirps(viewerID uuid.NullUUID, chirps ...*Chirp) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	// Rechirped originals are decorated like any other chirp.
+	all := make([]*Chirp, 0, len(chirps))
+	for _, chirp := range chirps {
+		if chirp.RechirpOf != nil {
+			all = append(all, chirp.RechirpOf)
+		} else {
+			all = append(all, chirp)
+		}
+	}
+
+	err := cfg.embedQuotedChirps(viewerID, all)
+	if err != nil {
+		return err
+	}
+	for _, chirp := range all {
+		if chirp.QuotedChirp != nil {
+			all = append(all, chirp.QuotedChirp)
+		}
+	}
+
+	err = cfg.embedMentions(all)
+	if err != nil {
+		return err
+	}
+
+	if !viewerID.Valid {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(all))
+	for i, chirp := range all {
+		ids[i] = chirp.ID
+	}
+
+	likedIDs, err := cfg.DB.GetLikedChirpIDs(context.Background(), database.GetLikedChirpIDsParams{
+		UserID:   viewerID.UUID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return err
+	}
+	liked := make(map[uuid.UUID]bool, len(likedIDs))
+	for _, id := range likedIDs {
+		liked[id] = true
+	}
+
+	bookmarkedIDs, err := cfg.DB.GetBookmarkedChirpIDs(context.Background(), database.GetBookmarkedChirpIDsParams{
+		UserID:   viewerID.UUID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return err
+	}
+	bookmarked := make(map[uuid.UUID]bool, len(bookmarkedIDs))
+	for _, id := range bookmarkedIDs {
+		bookmarked[id] = true
+	}
+
+	for _, chirp := range all {
+		chirp.LikedByMe = liked[chirp.ID]
+		chirp.BookmarkedByMe = bookmarked[chirp.ID]
+	}
+	return nil
+}
+
+// embedQuotedChirps embeds the originals of quotes that viewerID may see.
+// A quote whose original was deleted or is hidden from them is marked
+// unavailable instead.
+func (cfg *ApiConfig) embedQuotedChirps(viewerID uuid.NullUUID, chirps []*Chirp) error {
+	var quotedIDs []uuid.UUID
+	for _, chirp := range chirps {
+		if chirp.QuoteOfID != nil {
+			quotedIDs = append(quotedIDs, *chirp.QuoteOfID)
+		}
+	}
+	if len(quotedIDs) == 0 {
+		return nil
+	}
+
+	dbQuoted, err := cfg.DB.GetVisibleChirpsByIDs(context.Background(), database.GetVisibleChirpsByIDsParams{
+		Ids:      quotedIDs,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		return err
+	}
+	quoted := make(map[uuid.UUID]database.Chirp, len(dbQuoted))
+	for _, dbChirp := range dbQuoted {
+		quoted[dbChirp.ID] = dbChirp
+	}
+
+	for _, chirp := range chirps {
+		if chirp.QuoteOfID == nil {
+			continue
+		}
+		dbChirp, ok := quoted[*chirp.QuoteOfID]
+		if !ok {
+			chirp.QuoteUnavailable = true
+			continue
+		}
+		original := toChirp(dbChirp)
+		chirp.QuotedChirp = &original
+	}
+	return nil
+}
+
+func chirpPtrs(chirps []Chirp) []*Chirp {
+	ptrs := make([]*Chirp, len(chirps))
+	for i := range chirps {
+		ptrs[i] = &chirps[i]
+	}
+	return ptrs
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import "errors"
+
+// Who can see a chirp besides its author. Public chirps of private accounts
+// are only shown to followers; the filters live in the SQL queries.
+const (
+	visibilityPublic    = "public"
+	visibilityFollowers = "followers"
+	visibilityMentioned = "mentioned"
+)
+
+var errInvalidVisibility = errors.New("visibility must be public, followers or mentioned")
+
+// parseVisibility validates the visibility of a new chirp, defaulting to
+// public when none is given.
+func parseVisibility(visibility string) (string, error) {
+	switch visibility {
+	case "":
+		return visibilityPublic, nil
+	case visibilityPublic, visibilityFollowers, visibilityMentioned:
+		return visibility, nil
+	default:
+		return "", errInvalidVisibility
+	}
+}
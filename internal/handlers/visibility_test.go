@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseVisibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility string
+		want       string
+		wantErr    error
+	}{
+		{
+			name:       "Defaults to public",
+			visibility: "",
+			want:       visibilityPublic,
+		},
+		{
+			name:       "Public",
+			visibility: "public",
+			want:       visibilityPublic,
+		},
+		{
+			name:       "Followers only",
+			visibility: "followers",
+			want:       visibilityFollowers,
+		},
+		{
+			name:       "Mentioned only",
+			visibility: "mentioned",
+			want:       visibilityMentioned,
+		},
+		{
+			name:       "Unknown level",
+			visibility: "friends",
+			wantErr:    errInvalidVisibility,
+		},
+		{
+			name:       "Case sensitive",
+			visibility: "Public",
+			wantErr:    errInvalidVisibility,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVisibility(tt.visibility)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseVisibility(%q) error = %v, want %v", tt.visibility, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseVisibility(%q) = %q, want %q", tt.visibility, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/auth"
+	res "github.com/sebmaz93/gocial_server/internal/response"
+	"github.com/sebmaz93/gocial_server/internal/stream"
+)
+
+const (
+	wsPingInterval  = 30 * time.Second
+	wsWriteTimeout  = 10 * time.Second
+	wsOutboundQueue = 64
+
+	wsTopicTimeline      = "timeline"
+	wsTopicNotifications = "notifications"
+	wsTopicAuthorPrefix  = "author:"
+)
+
+// wsClientMessage is a message sent by a WebSocket client. Type is one of
+// "subscribe", "unsubscribe" or "auth"; the latter swaps in a fresh access
+// token so the connection outlives the one it was opened with.
+type wsClientMessage struct {
+	Type  string `json:"type"`
+	Topic string `json:"topic"`
+	Token string `json:"token"`
+}
+
+type wsServerMessage struct {
+	Type  string        `json:"type"`
+	Topic string        `json:"topic,omitempty"`
+	Event *stream.Event `json:"event,omitempty"`
+	Error string        `json:"error,omitempty"`
+}
+
+// wsSession is one authenticated WebSocket connection and its topic
+// subscriptions.
+type wsSession struct {
+	cfg    *ApiConfig
+	conn   *websocket.Conn
+	userID uuid.UUID
+	out    chan wsServerMessage
+	renew  chan time.Time
+
+	mu   sync.Mutex
+	subs map[string]*stream.Subscription
+
+	closeOnce   sync.Once
+	done        chan struct{}
+	closeCode   websocket.StatusCode
+	closeReason string
+}
+
+// HandleWebSocket serves GET /api/ws. The access token is taken from the
+// Authorization header or, for browsers which can't set it, the `token`
+// query parameter. Clients subscribe to "timeline", "notifications" or
+// "author:<user id>". The connection is closed when the token expires, and
+// when the client reads too slowly to keep up with its subscriptions.
+func (cfg *ApiConfig) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if cfg.Stream == nil {
+		res.RespondWithError(w, http.StatusServiceUnavailable, "Streaming is not enabled", nil)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error reading JWT", err)
+		return
+	}
+	claims, err := cfg.parseAccessToken(token)
+	if err != nil {
+		res.RespondWithError(w, http.StatusUnauthorized, "Error validating JWT", err)
+		return
+	}
+
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		log.Printf("Error accepting WebSocket: %s", err)
+		return
+	}
+
+	s := &wsSession{
+		cfg:    cfg,
+		conn:   conn,
+		userID: claims.UserID,
+		out:    make(chan wsServerMessage, wsOutboundQueue),
+		renew:  make(chan time.Time, 1),
+		subs:   map[string]*stream.Subscription{},
+		done:   make(chan struct{}),
+	}
+	s.run(r.Context(), claims.ExpiresAt)
+}
+
+func (s *wsSession) run(ctx context.Context, expiresAt time.Time) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer s.unsubscribeAll()
+
+	go s.readLoop(ctx)
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	expiry := time.NewTimer(time.Until(expiresAt))
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.conn.CloseNow()
+			return
+		case <-s.done:
+			s.conn.Close(s.closeCode, s.closeReason)
+			return
+		case <-expiry.C:
+			s.conn.Close(websocket.StatusPolicyViolation, "token expired")
+			return
+		case expiresAt := <-s.renew:
+			expiry.Reset(time.Until(expiresAt))
+		case msg := <-s.out:
+			err := s.write(ctx, msg)
+			if err != nil {
+				s.conn.CloseNow()
+				return
+			}
+		case <-ping.C:
+			pingCtx, cancelPing := context.WithTimeout(ctx, wsWriteTimeout)
+			err := s.conn.Ping(pingCtx)
+			cancelPing()
+			if err != nil {
+				s.conn.CloseNow()
+				return
+			}
+		}
+	}
+}
+
+func (s *wsSession) write(ctx context.Context, msg wsServerMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, wsWriteTimeout)
+	defer cancel()
+	return s.conn.Write(ctx, websocket.MessageText, data)
+}
+
+// readLoop handles client messages. It also keeps control frames flowing,
+// which pings rely on to see the pong.
+func (s *wsSession) readLoop(ctx context.Context) {
+	for {
+		_, data, err := s.conn.Read(ctx)
+		if err != nil {
+			s.close(websocket.StatusNormalClosure, "")
+			return
+		}
+
+		var msg wsClientMessage
+		err = json.Unmarshal(data, &msg)
+		if err != nil {
+			s.send(wsServerMessage{Type: "error", Error: "malformed message"})
+			continue
+		}
+
+		var ack string
+		switch msg.Type {
+		case "subscribe":
+			ack, err = "subscribed", s.subscribe(msg.Topic)
+		case "unsubscribe":
+			ack = "unsubscribed"
+			s.unsubscribe(msg.Topic)
+		case "auth":
+			ack, err = "authenticated", s.reauthenticate(msg.Token)
+		default:
+			err = errors.New("unknown message type")
+		}
+		if err != nil {
+			s.send(wsServerMessage{Type: "error", Topic: msg.Topic, Error: err.Error()})
+			continue
+		}
+		s.send(wsServerMessage{Type: ack, Topic: msg.Topic})
+	}
+}
+
+// send queues a message for the client. A client whose queue is full is
+// disconnected rather than letting messages pile up in memory.
+func (s *wsSession) send(msg wsServerMessage) {
+	select {
+	case s.out <- msg:
+	default:
+		s.close(websocket.StatusTryAgainLater, "client is too slow")
+	}
+}
+
+func (s *wsSession) close(code websocket.StatusCode, reason string) {
+	s.closeOnce.Do(func() {
+		s.closeCode = code
+		s.closeReason = reason
+		close(s.done)
+	})
+}
+
+func (s *wsSession) subscribe(topic string) error {
+	filter, err := s.topicFilter(topic)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subs == nil {
+		return errors.New("connection closed")
+	}
+	if _, ok := s.subs[topic]; ok {
+		return nil
+	}
+	sub, _ := s.cfg.Stream.Subscribe("", filter)
+	s.subs[topic] = sub
+
+	go func() {
+		for ev := range sub.C {
+			s.send(wsServerMessage{Type: "event", Topic: topic, Event: &ev})
+		}
+	}()
+	return nil
+}
+
+func (s *wsSession) unsubscribe(topic string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sub, ok := s.subs[topic]; ok {
+		s.cfg.Stream.Unsubscribe(sub)
+		delete(s.subs, topic)
+	}
+}
+
+func (s *wsSession) unsubscribeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.subs {
+		s.cfg.Stream.Unsubscribe(sub)
+	}
+	s.subs = nil
+}
+
+// topicFilter resolves a topic to a stream filter. The timeline covers the
+// accounts followed when subscribing, and chirps by users blocked either
+// way or muted when subscribing are left out; subscribe again to pick up
+// changes.
+func (s *wsSession) topicFilter(topic string) (func(stream.Event) bool, error) {
+	kind, authorID, err := parseWSTopic(topic)
+	if err != nil {
+		return nil, err
+	}
+	if kind == wsTopicNotifications {
+		return stream.ForRecipient(s.userID), nil
+	}
+
+	hidden, err := s.cfg.DB.GetHiddenUserIDs(context.Background(), s.userID)
+	if err != nil {
+		return nil, errors.New("couldn't load blocked and muted users")
+	}
+	if kind == wsTopicTimeline {
+		followees, err := s.cfg.DB.GetFolloweeIDs(context.Background(), s.userID)
+		if err != nil {
+			return nil, errors.New("couldn't load timeline")
+		}
+		return stream.ExceptAuthors(stream.ByAuthors(append(followees, s.userID)), hidden), nil
+	}
+	return stream.ExceptAuthors(stream.ByAuthor(authorID), hidden), nil
+}
+
+// reauthenticate extends the connection with a newer token for the same
+// user.
+func (s *wsSession) reauthenticate(token string) error {
+	claims, err := s.cfg.parseAccessToken(token)
+	if err != nil {
+		return errors.New("invalid token")
+	}
+	if claims.UserID != s.userID {
+		return errors.New("token belongs to another user")
+	}
+
+	select {
+	case <-s.renew:
+	default:
+	}
+	s.renew <- claims.ExpiresAt
+	return nil
+}
+
+// parseWSTopic splits a topic into its kind and, for author topics, the
+// author's ID.
+func parseWSTopic(topic string) (string, uuid.UUID, error) {
+	switch {
+	case topic == wsTopicTimeline, topic == wsTopicNotifications:
+		return topic, uuid.Nil, nil
+	case strings.HasPrefix(topic, wsTopicAuthorPrefix):
+		authorID, err := uuid.Parse(strings.TrimPrefix(topic, wsTopicAuthorPrefix))
+		if err != nil {
+			return "", uuid.Nil, errors.New("malformed user id")
+		}
+		return wsTopicAuthorPrefix, authorID, nil
+	default:
+		return "", uuid.Nil, errors.New("unknown topic")
+	}
+}
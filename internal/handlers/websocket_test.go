@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseWSTopic(t *testing.T) {
+	authorID := uuid.New()
+
+	tests := []struct {
+		name       string
+		topic      string
+		wantKind   string
+		wantAuthor uuid.UUID
+		wantErr    bool
+	}{
+		{name: "Timeline", topic: "timeline", wantKind: wsTopicTimeline},
+		{name: "Notifications", topic: "notifications", wantKind: wsTopicNotifications},
+		{name: "Author", topic: "author:" + authorID.String(), wantKind: wsTopicAuthorPrefix, wantAuthor: authorID},
+		{name: "Malformed author", topic: "author:nope", wantErr: true},
+		{name: "Unknown", topic: "everything", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, author, err := parseWSTopic(tt.topic)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWSTopic(%q) error = %v, wantErr %v", tt.topic, err, tt.wantErr)
+			}
+			if kind != tt.wantKind || author != tt.wantAuthor {
+				t.Errorf("parseWSTopic(%q) = %q, %v, want %q, %v", tt.topic, kind, author, tt.wantKind, tt.wantAuthor)
+			}
+		})
+	}
+}
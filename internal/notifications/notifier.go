@@ -0,0 +1,91 @@
+// Package notifications records notifications for users outside of the
+// request that caused them.
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/database"
+)
+
+const (
+	TypeLike       = "like"
+	TypeReply      = "reply"
+	TypeFollow     = "follow"
+	TypeMention    = "mention"
+	TypeRedUpgrade = "red_upgrade"
+)
+
+// Notifier queues notifications and stores them from a background
+// goroutine, so write paths only pay for a channel send. Notifications are
+// best effort: when the queue is full they are dropped rather than making
+// the request wait.
+type Notifier struct {
+	db       *database.Queries
+	queue    chan database.CreateNotificationParams
+	onStored func(database.Notification)
+}
+
+func NewNotifier(db *database.Queries, queueSize int) *Notifier {
+	return &Notifier{
+		db:    db,
+		queue: make(chan database.CreateNotificationParams, queueSize),
+	}
+}
+
+// OnStored registers a function called with every notification once it has
+// been stored, for example to push it to connected clients. It must be
+// called before Run.
+func (n *Notifier) OnStored(fn func(database.Notification)) {
+	n.onStored = fn
+}
+
+// Notify queues a notification for userID. actorID may be uuid.Nil for
+// system events and chirpID uuid.Nil when no chirp is involved. Users are
+// never notified about their own actions, nor about actions of users they
+// mute or who are blocked either way. Notify is safe to call on a nil
+// Notifier.
+func (n *Notifier) Notify(userID uuid.UUID, notificationType string, actorID, chirpID uuid.UUID) {
+	if n == nil || userID == actorID {
+		return
+	}
+
+	params := database.CreateNotificationParams{
+		UserID:  userID,
+		ActorID: uuid.NullUUID{UUID: actorID, Valid: actorID != uuid.Nil},
+		Type:    notificationType,
+		ChirpID: uuid.NullUUID{UUID: chirpID, Valid: chirpID != uuid.Nil},
+	}
+	select {
+	case n.queue <- params:
+	default:
+		log.Printf("Notification queue full, dropping %s notification for %s", notificationType, userID)
+	}
+}
+
+// Run stores queued notifications until ctx is cancelled.
+func (n *Notifier) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case params := <-n.queue:
+			notification, err := n.db.CreateNotification(context.Background(), params)
+			if errors.Is(err, sql.ErrNoRows) {
+				// Muted or blocked, so neither stored nor pushed.
+				continue
+			}
+			if err != nil {
+				log.Printf("Error storing notification: %s", err)
+				continue
+			}
+			if n.onStored != nil {
+				n.onStored(notification)
+			}
+		}
+	}
+}
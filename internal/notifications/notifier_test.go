@@ -0,0 +1,62 @@
+package notifications
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNotify(t *testing.T) {
+	userID := uuid.New()
+	actorID := uuid.New()
+	chirpID := uuid.New()
+
+	t.Run("Queues notification", func(t *testing.T) {
+		n := NewNotifier(nil, 1)
+		n.Notify(userID, TypeLike, actorID, chirpID)
+		if len(n.queue) != 1 {
+			t.Fatalf("queue length = %d, want 1", len(n.queue))
+		}
+		params := <-n.queue
+		if params.UserID != userID || params.Type != TypeLike {
+			t.Errorf("queued %+v", params)
+		}
+		if !params.ActorID.Valid || params.ActorID.UUID != actorID {
+			t.Errorf("ActorID = %v, want %v", params.ActorID, actorID)
+		}
+		if !params.ChirpID.Valid || params.ChirpID.UUID != chirpID {
+			t.Errorf("ChirpID = %v, want %v", params.ChirpID, chirpID)
+		}
+	})
+
+	t.Run("System events have no actor", func(t *testing.T) {
+		n := NewNotifier(nil, 1)
+		n.Notify(userID, TypeRedUpgrade, uuid.Nil, uuid.Nil)
+		params := <-n.queue
+		if params.ActorID.Valid || params.ChirpID.Valid {
+			t.Errorf("expected null actor and chirp, got %+v", params)
+		}
+	})
+
+	t.Run("Skips own actions", func(t *testing.T) {
+		n := NewNotifier(nil, 1)
+		n.Notify(userID, TypeLike, userID, chirpID)
+		if len(n.queue) != 0 {
+			t.Errorf("queue length = %d, want 0", len(n.queue))
+		}
+	})
+
+	t.Run("Drops when full", func(t *testing.T) {
+		n := NewNotifier(nil, 1)
+		n.Notify(userID, TypeFollow, actorID, uuid.Nil)
+		n.Notify(userID, TypeFollow, actorID, uuid.Nil)
+		if len(n.queue) != 1 {
+			t.Errorf("queue length = %d, want 1", len(n.queue))
+		}
+	})
+
+	t.Run("Nil notifier", func(t *testing.T) {
+		var n *Notifier
+		n.Notify(userID, TypeFollow, actorID, uuid.Nil)
+	})
+}
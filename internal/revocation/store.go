@@ -0,0 +1,181 @@
+// Package revocation keeps the list of access tokens revoked before they
+// expired, so they can be rejected without a database query per request.
+package revocation
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sebmaz93/gocial_server/internal/database"
+)
+
+// syncOverlap is how far back each refresh looks past the newest
+// revocation already seen. revoked_at is set when the revoking transaction
+// starts, so a slow transaction can commit a row older than one already
+// loaded.
+const syncOverlap = time.Minute
+
+// Store is an in-memory copy of the revoked_access_tokens table, keyed by
+// jti, and of users' tokens_valid_after, the moment they last revoked all
+// of their tokens. Revocations made by other instances are picked up by
+// Refresh, so they take effect within one refresh interval.
+type Store struct {
+	db *database.Queries
+	// tokenLifetime is how long access tokens are valid for; a
+	// tokens_valid_after older than that can't reject anything.
+	tokenLifetime time.Duration
+
+	mu         sync.RWMutex
+	revoked    map[string]time.Time
+	syncedTo   time.Time
+	validAfter map[uuid.UUID]time.Time
+	// validAfterSyncedTo is the newest tokens_valid_after loaded.
+	validAfterSyncedTo time.Time
+}
+
+func NewStore(db *database.Queries, tokenLifetime time.Duration) *Store {
+	return &Store{
+		db:            db,
+		tokenLifetime: tokenLifetime,
+		revoked:       map[string]time.Time{},
+		validAfter:    map[uuid.UUID]time.Time{},
+	}
+}
+
+// IsRevoked reports whether the token with the given jti has been revoked.
+// IsRevoked is safe to call on a nil Store, which revokes nothing.
+func (s *Store) IsRevoked(jti string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.revoked[jti]
+	return ok
+}
+
+// IsIssuedBeforeValidAfter reports whether a token issued to userID at
+// issuedAt predates the last time the user revoked all of their tokens.
+// A token issued in the same instant may predate it, so it counts too.
+// IsIssuedBeforeValidAfter is safe to call on a nil Store.
+func (s *Store) IsIssuedBeforeValidAfter(userID uuid.UUID, issuedAt time.Time) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	validAfter, ok := s.validAfter[userID]
+	return ok && !issuedAt.After(validAfter)
+}
+
+// SetValidAfter records on this instance, without waiting for the next
+// refresh, that userID's tokens issued until validAfter are revoked.
+func (s *Store) SetValidAfter(userID uuid.UUID, validAfter time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if validAfter.After(s.validAfter[userID]) {
+		s.validAfter[userID] = validAfter
+	}
+}
+
+// Add marks a token as revoked on this instance until expiresAt, without
+// waiting for the next refresh.
+func (s *Store) Add(jti string, expiresAt time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[jti] = expiresAt
+}
+
+// Refresh loads revocations stored since the last refresh and forgets
+// tokens that have expired, since those are rejected anyway.
+func (s *Store) Refresh(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	now := time.Now()
+	s.mu.RLock()
+	since := s.syncedTo.Add(-syncOverlap)
+	// tokens_valid_after is set from the revoking instance's clock before
+	// its transaction commits, so it gets the same overlap.
+	validAfterSince := s.validAfterSyncedTo.Add(-syncOverlap)
+	s.mu.RUnlock()
+	if oldest := now.Add(-s.tokenLifetime); validAfterSince.Before(oldest) {
+		validAfterSince = oldest
+	}
+
+	rows, err := s.db.GetRevokedAccessTokensSince(ctx, since)
+	if err != nil {
+		return err
+	}
+	users, err := s.db.GetUsersTokensValidAfterSince(ctx, sql.NullTime{Time: validAfterSince, Valid: true})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, row := range rows {
+		s.revoked[row.JTI] = row.ExpiresAt
+		if row.RevokedAt.After(s.syncedTo) {
+			s.syncedTo = row.RevokedAt
+		}
+	}
+	for _, user := range users {
+		validAfter := user.TokensValidAfter.Time
+		if validAfter.After(s.validAfter[user.ID]) {
+			s.validAfter[user.ID] = validAfter
+		}
+		if validAfter.After(s.validAfterSyncedTo) {
+			s.validAfterSyncedTo = validAfter
+		}
+	}
+	s.prune(now)
+	return nil
+}
+
+// prune drops tokens that expired before now, and revoke-alls older than
+// any token still valid at now. The caller holds s.mu.
+func (s *Store) prune(now time.Time) {
+	for jti, expiresAt := range s.revoked {
+		if !expiresAt.After(now) {
+			delete(s.revoked, jti)
+		}
+	}
+	for userID, validAfter := range s.validAfter {
+		if !validAfter.Add(s.tokenLifetime).After(now) {
+			delete(s.validAfter, userID)
+		}
+	}
+}
+
+// Run refreshes the store every interval until ctx is cancelled, and
+// deletes rows for tokens that have expired.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.Refresh(ctx)
+			if err != nil {
+				log.Printf("Error refreshing revoked access tokens: %s", err)
+			}
+			err = s.db.DeleteExpiredRevokedAccessTokens(ctx)
+			if err != nil {
+				log.Printf("Error deleting expired revoked access tokens: %s", err)
+			}
+		}
+	}
+}
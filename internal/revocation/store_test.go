@@ -0,0 +1,85 @@
+package revocation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Added tokens are revoked", func(t *testing.T) {
+		s := NewStore(nil, time.Hour)
+		s.Add("a", now.Add(time.Hour))
+		if !s.IsRevoked("a") {
+			t.Error("IsRevoked(a) = false, want true")
+		}
+		if s.IsRevoked("b") {
+			t.Error("IsRevoked(b) = true, want false")
+		}
+	})
+
+	t.Run("Prune forgets expired tokens", func(t *testing.T) {
+		s := NewStore(nil, time.Hour)
+		s.Add("expired", now.Add(-time.Second))
+		s.Add("live", now.Add(time.Hour))
+		s.prune(now)
+		if s.IsRevoked("expired") {
+			t.Error("IsRevoked(expired) = true, want false")
+		}
+		if !s.IsRevoked("live") {
+			t.Error("IsRevoked(live) = false, want true")
+		}
+	})
+
+	t.Run("Tokens issued before valid after are revoked", func(t *testing.T) {
+		s := NewStore(nil, time.Hour)
+		userID, otherID := uuid.New(), uuid.New()
+		s.SetValidAfter(userID, now)
+		s.SetValidAfter(userID, now.Add(-time.Minute))
+		tests := []struct {
+			name     string
+			userID   uuid.UUID
+			issuedAt time.Time
+			want     bool
+		}{
+			{name: "Before", userID: userID, issuedAt: now.Add(-time.Millisecond), want: true},
+			{name: "Same instant", userID: userID, issuedAt: now, want: true},
+			{name: "After", userID: userID, issuedAt: now.Add(time.Millisecond), want: false},
+			{name: "Other user", userID: otherID, issuedAt: now.Add(-time.Millisecond), want: false},
+		}
+		for _, tt := range tests {
+			if got := s.IsIssuedBeforeValidAfter(tt.userID, tt.issuedAt); got != tt.want {
+				t.Errorf("%s: IsIssuedBeforeValidAfter() = %v, want %v", tt.name, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("Prune forgets valid afters older than any live token", func(t *testing.T) {
+		s := NewStore(nil, time.Hour)
+		oldID, recentID := uuid.New(), uuid.New()
+		s.SetValidAfter(oldID, now.Add(-time.Hour))
+		s.SetValidAfter(recentID, now.Add(-time.Minute))
+		s.prune(now)
+		if _, ok := s.validAfter[oldID]; ok {
+			t.Error("prune kept a valid after older than the token lifetime")
+		}
+		if _, ok := s.validAfter[recentID]; !ok {
+			t.Error("prune dropped a recent valid after")
+		}
+	})
+
+	t.Run("Nil store", func(t *testing.T) {
+		var s *Store
+		s.Add("a", now.Add(time.Hour))
+		if s.IsRevoked("a") {
+			t.Error("IsRevoked(a) = true on nil store")
+		}
+		s.SetValidAfter(uuid.New(), now)
+		if s.IsIssuedBeforeValidAfter(uuid.New(), now) {
+			t.Error("IsIssuedBeforeValidAfter() = true on nil store")
+		}
+	})
+}
@@ -0,0 +1,204 @@
+// Package stream fans chirp events out to long-lived client connections.
+package stream
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventChirpCreated = "chirp.created"
+	EventChirpDeleted = "chirp.deleted"
+	EventNotification = "notification"
+)
+
+const subscriptionBuffer = 64
+
+// Event is a change pushed to subscribers. IDs are unique across server
+// instances so clients can resume from any of them. Events with a
+// RecipientID are private to that user.
+type Event struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	AuthorID    uuid.UUID       `json:"author_id"`
+	RecipientID uuid.NullUUID   `json:"recipient_id"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// Subscription receives the events accepted by its filter on C. C is
+// closed when the subscriber falls too far behind or unsubscribes.
+type Subscription struct {
+	C      <-chan Event
+	events chan Event
+	filter func(Event) bool
+}
+
+// Hub is an in-process pub/sub hub. It keeps the most recent events so
+// reconnecting clients can catch up on what they missed.
+type Hub struct {
+	mu      sync.Mutex
+	subs    map[*Subscription]struct{}
+	history []Event
+	size    int
+	relay   func(Event)
+}
+
+func NewHub(historySize int) *Hub {
+	return &Hub{
+		subs: map[*Subscription]struct{}{},
+		size: historySize,
+	}
+}
+
+// Publish assigns the event an ID, delivers it to local subscribers and
+// hands it to the relay, if any, for other instances. Publish never blocks
+// on slow subscribers and is safe to call on a nil Hub.
+func (h *Hub) Publish(ev Event) {
+	if h == nil {
+		return
+	}
+	if ev.ID == "" {
+		ev.ID = uuid.NewString()
+	}
+	h.Deliver(ev)
+
+	h.mu.Lock()
+	relay := h.relay
+	h.mu.Unlock()
+	if relay != nil {
+		relay(ev)
+	}
+}
+
+// Deliver records an event and sends it to local subscribers only. It is
+// used for events that arrive from other instances.
+func (h *Hub) Deliver(ev Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.history = append(h.history, ev)
+	if len(h.history) > h.size {
+		h.history = h.history[len(h.history)-h.size:]
+	}
+
+	for sub := range h.subs {
+		if !sub.filter(ev) {
+			continue
+		}
+		select {
+		case sub.events <- ev:
+		default:
+			// The client isn't keeping up; drop it rather than buffering
+			// without bound. It can reconnect and resume.
+			h.remove(sub)
+		}
+	}
+}
+
+// SetRelay registers a function that forwards published events to other
+// server instances.
+func (h *Hub) SetRelay(relay func(Event)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.relay = relay
+}
+
+// Subscribe registers a subscriber and returns the buffered events after
+// lastEventID that match filter. Registration and the backlog are taken
+// under one lock so no event is missed or sent twice. An unknown or empty
+// lastEventID yields no backlog.
+func (h *Hub) Subscribe(lastEventID string, filter func(Event) bool) (*Subscription, []Event) {
+	events := make(chan Event, subscriptionBuffer)
+	sub := &Subscription{
+		C:      events,
+		events: events,
+		filter: filter,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	backlog := []Event{}
+	if lastEventID != "" {
+		for i := len(h.history) - 1; i >= 0; i-- {
+			if h.history[i].ID != lastEventID {
+				continue
+			}
+			for _, ev := range h.history[i+1:] {
+				if filter(ev) {
+					backlog = append(backlog, ev)
+				}
+			}
+			break
+		}
+	}
+
+	h.subs[sub] = struct{}{}
+	return sub, backlog
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.events)
+	}
+}
+
+// Public is a filter that accepts every event not addressed to a single
+// user.
+func Public(ev Event) bool {
+	return !ev.RecipientID.Valid
+}
+
+// ByAuthor returns a filter that accepts public events about authorID's
+// chirps.
+func ByAuthor(authorID uuid.UUID) func(Event) bool {
+	return func(ev Event) bool {
+		return Public(ev) && ev.AuthorID == authorID
+	}
+}
+
+// ByAuthors returns a filter that accepts public events about chirps by any
+// of authorIDs.
+func ByAuthors(authorIDs []uuid.UUID) func(Event) bool {
+	authors := make(map[uuid.UUID]struct{}, len(authorIDs))
+	for _, id := range authorIDs {
+		authors[id] = struct{}{}
+	}
+	return func(ev Event) bool {
+		_, ok := authors[ev.AuthorID]
+		return Public(ev) && ok
+	}
+}
+
+// ExceptAuthors returns a filter that accepts the events filter accepts,
+// except those about chirps by any of authorIDs.
+func ExceptAuthors(filter func(Event) bool, authorIDs []uuid.UUID) func(Event) bool {
+	if len(authorIDs) == 0 {
+		return filter
+	}
+	excluded := make(map[uuid.UUID]struct{}, len(authorIDs))
+	for _, id := range authorIDs {
+		excluded[id] = struct{}{}
+	}
+	return func(ev Event) bool {
+		_, ok := excluded[ev.AuthorID]
+		return !ok && filter(ev)
+	}
+}
+
+// ForRecipient returns a filter that accepts the private events addressed
+// to userID.
+func ForRecipient(userID uuid.UUID) func(Event) bool {
+	return func(ev Event) bool {
+		return ev.RecipientID.Valid && ev.RecipientID.UUID == userID
+	}
+}
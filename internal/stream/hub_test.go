@@ -0,0 +1,104 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHubFilter(t *testing.T) {
+	hub := NewHub(10)
+	author := uuid.New()
+	sub, _ := hub.Subscribe("", ByAuthor(author))
+	defer hub.Unsubscribe(sub)
+
+	hub.Publish(Event{Type: EventChirpCreated, AuthorID: uuid.New()})
+	hub.Publish(Event{Type: EventChirpCreated, AuthorID: author})
+
+	if len(sub.C) != 1 {
+		t.Fatalf("received %d events, want 1", len(sub.C))
+	}
+	if ev := <-sub.C; ev.AuthorID != author || ev.ID == "" {
+		t.Errorf("unexpected event %+v", ev)
+	}
+}
+
+func TestExceptAuthors(t *testing.T) {
+	hidden, other := uuid.New(), uuid.New()
+	filter := ExceptAuthors(ByAuthors([]uuid.UUID{hidden, other}), []uuid.UUID{hidden})
+
+	if filter(Event{Type: EventChirpCreated, AuthorID: hidden}) {
+		t.Error("filter accepted an event by an excluded author")
+	}
+	if !filter(Event{Type: EventChirpCreated, AuthorID: other}) {
+		t.Error("filter rejected an event by another author")
+	}
+}
+
+func TestHubPrivateEvents(t *testing.T) {
+	hub := NewHub(10)
+	recipient := uuid.New()
+	public, _ := hub.Subscribe("", Public)
+	defer hub.Unsubscribe(public)
+	private, _ := hub.Subscribe("", ForRecipient(recipient))
+	defer hub.Unsubscribe(private)
+
+	hub.Publish(Event{
+		Type:        EventNotification,
+		RecipientID: uuid.NullUUID{UUID: recipient, Valid: true},
+	})
+
+	if len(public.C) != 0 {
+		t.Errorf("public subscriber received %d private events", len(public.C))
+	}
+	if len(private.C) != 1 {
+		t.Errorf("recipient received %d events, want 1", len(private.C))
+	}
+}
+
+func TestHubResume(t *testing.T) {
+	hub := NewHub(3)
+	for range 5 {
+		hub.Publish(Event{Type: EventChirpCreated})
+	}
+	history := hub.history
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		want        int
+	}{
+		{name: "No last event", lastEventID: "", want: 0},
+		{name: "Unknown event", lastEventID: "gone", want: 0},
+		{name: "Oldest buffered", lastEventID: history[0].ID, want: 2},
+		{name: "Newest", lastEventID: history[2].ID, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, backlog := hub.Subscribe(tt.lastEventID, Public)
+			defer hub.Unsubscribe(sub)
+			if len(backlog) != tt.want {
+				t.Errorf("backlog has %d events, want %d", len(backlog), tt.want)
+			}
+		})
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := NewHub(1)
+	sub, _ := hub.Subscribe("", Public)
+
+	for range subscriptionBuffer + 1 {
+		hub.Publish(Event{Type: EventChirpCreated})
+	}
+
+	n := 0
+	for range sub.C {
+		n++
+	}
+	if n != subscriptionBuffer {
+		t.Errorf("received %d events before close, want %d", n, subscriptionBuffer)
+	}
+	hub.Unsubscribe(sub)
+}
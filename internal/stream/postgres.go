@@ -0,0 +1,88 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	notifyChannel   = "chirp_events"
+	relayQueueSize  = 256
+	minReconnectGap = 10 * time.Second
+	maxReconnectGap = time.Minute
+)
+
+// envelope is the NOTIFY payload. Origin lets an instance skip the events
+// it published itself, which it has already delivered locally.
+type envelope struct {
+	Origin string `json:"origin"`
+	Event  Event  `json:"event"`
+}
+
+// ListenPostgres shares the hub's events with other server instances
+// through Postgres LISTEN/NOTIFY. It returns once the listener is set up
+// and keeps relaying until ctx is cancelled.
+func ListenPostgres(ctx context.Context, dbURL string, db *sql.DB, hub *Hub) error {
+	listener := pq.NewListener(dbURL, minReconnectGap, maxReconnectGap, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Stream listener error: %s", err)
+		}
+	})
+	err := listener.Listen(notifyChannel)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+
+	origin := uuid.NewString()
+	outgoing := make(chan Event, relayQueueSize)
+	hub.SetRelay(func(ev Event) {
+		select {
+		case outgoing <- ev:
+		default:
+			log.Printf("Stream relay queue full, event %s not shared", ev.ID)
+		}
+	})
+
+	go func() {
+		defer listener.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev := <-outgoing:
+				payload, err := json.Marshal(envelope{Origin: origin, Event: ev})
+				if err != nil {
+					log.Printf("Error encoding stream event: %s", err)
+					continue
+				}
+				_, err = db.ExecContext(ctx, "SELECT pg_notify($1, $2)", notifyChannel, string(payload))
+				if err != nil {
+					log.Printf("Error relaying stream event: %s", err)
+				}
+			case n := <-listener.Notify:
+				// A nil notification means the connection was re-established;
+				// events sent meanwhile are lost.
+				if n == nil {
+					continue
+				}
+				var env envelope
+				err := json.Unmarshal([]byte(n.Extra), &env)
+				if err != nil {
+					log.Printf("Error decoding stream event: %s", err)
+					continue
+				}
+				if env.Origin != origin {
+					hub.Deliver(env.Event)
+				}
+			}
+		}
+	}()
+	return nil
+}
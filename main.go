@@ -1,17 +1,23 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"sync/atomic"
+	"time"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	"github.com/sebmaz93/gocial_server/internal/auth"
 	"github.com/sebmaz93/gocial_server/internal/database"
 	"github.com/sebmaz93/gocial_server/internal/handlers"
+	"github.com/sebmaz93/gocial_server/internal/notifications"
+	"github.com/sebmaz93/gocial_server/internal/revocation"
+	"github.com/sebmaz93/gocial_server/internal/stream"
 )
 
 func main() {
@@ -31,22 +37,66 @@ func main() {
 	if ENV == "" {
 		log.Fatal("ENV variable must be set")
 	}
-	JWTSecret := os.Getenv("JWT_SECRET")
-	if JWTSecret == "" {
-		log.Fatal("JWT_SECRET variable must be set")
+	// Access tokens are signed with the key named by JWT_SIGNING_KEY_ID and
+	// accepted from any key in JWT_KEYS_DIR; see auth.KeySet for rotation.
+	JWTKeysDir := os.Getenv("JWT_KEYS_DIR")
+	if JWTKeysDir == "" {
+		log.Fatal("JWT_KEYS_DIR variable must be set")
+	}
+	JWTSigningKeyID := os.Getenv("JWT_SIGNING_KEY_ID")
+	if JWTSigningKeyID == "" {
+		log.Fatal("JWT_SIGNING_KEY_ID variable must be set")
+	}
+	JWTKeys, err := auth.LoadKeySet(JWTKeysDir, JWTSigningKeyID)
+	if err != nil {
+		log.Fatal("failed to load JWT keys: ", err)
 	}
 	PolkaKey := os.Getenv("POLKA_KEY")
 	if PolkaKey == "" {
 		log.Fatal("POLKA_KEY variable must be set")
 	}
+	// Moderation endpoints under /admin/users take this key.
+	AdminKey := os.Getenv("ADMIN_KEY")
+	if AdminKey == "" {
+		log.Fatal("ADMIN_KEY variable must be set")
+	}
+	hub := stream.NewHub(1000)
+	// Multiple instances share stream events through Postgres when enabled.
+	if os.Getenv("STREAM_LISTEN_NOTIFY") == "true" {
+		err = stream.ListenPostgres(context.Background(), dbURL, db, hub)
+		if err != nil {
+			log.Fatal("failed to listen for stream events: ", err)
+		}
+	}
+
+	// Revoked access tokens are loaded before serving so none slip through
+	// at startup, then kept in sync with other instances.
+	// Access tokens are issued for an hour.
+	revocations := revocation.NewStore(dbQueries, time.Hour)
+	err = revocations.Refresh(context.Background())
+	if err != nil {
+		log.Fatal("failed to load revoked access tokens: ", err)
+	}
+	go revocations.Run(context.Background(), 30*time.Second)
+
 	apiCfg := handlers.ApiConfig{
 		FileserverHits: atomic.Int32{},
 		DB:             dbQueries,
+		DBConn:         db,
+		Stream:         hub,
 		ENV:            ENV,
-		JWTSecret:      JWTSecret,
+		JWTKeys:        JWTKeys,
+		Revocations:    revocations,
 		POLKA:          PolkaKey,
+		AdminKey:       AdminKey,
 	}
 
+	notifier := notifications.NewNotifier(dbQueries, 1024)
+	notifier.OnStored(apiCfg.PublishNotification)
+	apiCfg.Notifier = notifier
+	go notifier.Run(context.Background())
+	go apiCfg.RunScheduledChirps(context.Background(), 15*time.Second)
+
 	dir := http.Dir(rootPath)
 	fileServer := http.FileServer(dir)
 
@@ -54,16 +104,88 @@ func main() {
 	mux.Handle("/app/", apiCfg.MiddlewareMetricsInc(http.StripPrefix("/app", fileServer)))
 	mux.HandleFunc("GET /admin/metrics", apiCfg.HandleMetrics)
 	mux.HandleFunc("POST /admin/reset", apiCfg.HandleResetMetrics)
+	mux.HandleFunc("POST /admin/users/{userID}/suspend", apiCfg.HandleSuspendUser)
+	mux.HandleFunc("DELETE /admin/users/{userID}/suspend", apiCfg.HandleUnsuspendUser)
 	mux.HandleFunc("GET /api/healthz", handlers.HandlerHealth)
+	mux.HandleFunc("GET /.well-known/jwks.json", apiCfg.HandleJWKS)
 	mux.HandleFunc("POST /api/users", apiCfg.HandleCreateUser)
 	mux.HandleFunc("POST /api/login", apiCfg.HandleLogin)
+	mux.HandleFunc("POST /api/login/mfa", apiCfg.HandleLoginMFA)
 	mux.HandleFunc("POST /api/chirps", apiCfg.HandleCreateChirp)
 	mux.HandleFunc("GET /api/chirps", apiCfg.HandleGetAllChirps)
+	mux.HandleFunc("GET /api/chirps/search", apiCfg.HandleSearchChirps)
+	mux.HandleFunc("GET /api/chirps/scheduled", apiCfg.HandleGetScheduledChirps)
+	mux.HandleFunc("PUT /api/chirps/scheduled/{scheduledID}", apiCfg.HandleUpdateScheduledChirp)
+	mux.HandleFunc("POST /api/chirps/scheduled/{scheduledID}/cancel", apiCfg.HandleCancelScheduledChirp)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.HandleDeleteChirpByID)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.HandleGetChirpByID)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", apiCfg.HandleUpdateChirp)
+	mux.HandleFunc("PATCH /api/chirps/{chirpID}", apiCfg.HandleUpdateChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", apiCfg.HandleGetChirpRevisions)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/replies", apiCfg.HandleGetChirpReplies)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiCfg.HandleGetChirpThread)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/like", apiCfg.HandleLikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", apiCfg.HandleUnlikeChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", apiCfg.HandleRechirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", apiCfg.HandleUndoRechirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", apiCfg.HandleBookmarkChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", apiCfg.HandleUnbookmarkChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/pin", apiCfg.HandlePinChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", apiCfg.HandleUnpinChirp)
 	mux.HandleFunc("POST /api/refresh", apiCfg.HandleRefreshToken)
 	mux.HandleFunc("POST /api/revoke", apiCfg.HandleRevokeToken)
+	mux.HandleFunc("GET /api/sessions", apiCfg.HandleGetSessions)
+	mux.HandleFunc("DELETE /api/sessions/{sessionID}", apiCfg.HandleRevokeSession)
+	mux.HandleFunc("POST /api/sessions/revoke-all", apiCfg.HandleRevokeAllSessions)
 	mux.HandleFunc("PUT /api/users", apiCfg.HandleUpdateUser)
+	mux.HandleFunc("POST /api/users/2fa/enroll", apiCfg.HandleEnrollTOTP)
+	mux.HandleFunc("POST /api/users/2fa/confirm", apiCfg.HandleConfirmTOTP)
+	mux.HandleFunc("DELETE /api/users/2fa", apiCfg.HandleDisableTOTP)
+	mux.HandleFunc("GET /api/users/{handle}", apiCfg.HandleGetUserProfile)
+	mux.HandleFunc("POST /api/users/{userID}/follow", apiCfg.HandleFollowUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.HandleUnfollowUser)
+	mux.HandleFunc("POST /api/users/{userID}/block", apiCfg.HandleBlockUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/block", apiCfg.HandleUnblockUser)
+	mux.HandleFunc("POST /api/users/{userID}/mute", apiCfg.HandleMuteUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", apiCfg.HandleUnmuteUser)
+	mux.HandleFunc("GET /api/users/{userID}/followers", apiCfg.HandleGetFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", apiCfg.HandleGetFollowing)
+	mux.HandleFunc("GET /api/users/{userID}/likes", apiCfg.HandleGetUserLikes)
+	mux.HandleFunc("GET /api/follow_requests", apiCfg.HandleGetFollowRequests)
+	mux.HandleFunc("POST /api/follow_requests/{userID}/approve", apiCfg.HandleApproveFollowRequest)
+	mux.HandleFunc("DELETE /api/follow_requests/{userID}", apiCfg.HandleDenyFollowRequest)
+	mux.HandleFunc("GET /api/timeline", apiCfg.HandleGetTimeline)
+	mux.HandleFunc("GET /api/bookmarks", apiCfg.HandleGetBookmarks)
+	mux.HandleFunc("POST /api/drafts", apiCfg.HandleCreateDraft)
+	mux.HandleFunc("GET /api/drafts", apiCfg.HandleGetDrafts)
+	mux.HandleFunc("GET /api/drafts/{draftID}", apiCfg.HandleGetDraft)
+	mux.HandleFunc("PUT /api/drafts/{draftID}", apiCfg.HandleUpdateDraft)
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", apiCfg.HandleDeleteDraft)
+	mux.HandleFunc("POST /api/drafts/{draftID}/publish", apiCfg.HandlePublishDraft)
+	mux.HandleFunc("POST /api/lists", apiCfg.HandleCreateList)
+	mux.HandleFunc("GET /api/lists", apiCfg.HandleGetLists)
+	mux.HandleFunc("GET /api/lists/{listID}", apiCfg.HandleGetList)
+	mux.HandleFunc("PUT /api/lists/{listID}", apiCfg.HandleRenameList)
+	mux.HandleFunc("DELETE /api/lists/{listID}", apiCfg.HandleDeleteList)
+	mux.HandleFunc("GET /api/lists/{listID}/members", apiCfg.HandleGetListMembers)
+	mux.HandleFunc("POST /api/lists/{listID}/members", apiCfg.HandleAddListMember)
+	mux.HandleFunc("DELETE /api/lists/{listID}/members/{userID}", apiCfg.HandleRemoveListMember)
+	mux.HandleFunc("GET /api/lists/{listID}/timeline", apiCfg.HandleGetListTimeline)
+	mux.HandleFunc("GET /api/mentions", apiCfg.HandleGetMentions)
+	mux.HandleFunc("GET /api/stream", apiCfg.HandleStream)
+	mux.HandleFunc("GET /api/ws", apiCfg.HandleWebSocket)
+	mux.HandleFunc("POST /api/conversations", apiCfg.HandleCreateConversation)
+	mux.HandleFunc("GET /api/conversations", apiCfg.HandleGetConversations)
+	mux.HandleFunc("GET /api/conversations/{conversationID}", apiCfg.HandleGetConversation)
+	mux.HandleFunc("GET /api/conversations/{conversationID}/messages", apiCfg.HandleGetMessages)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/messages", apiCfg.HandleCreateMessage)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/read", apiCfg.HandleMarkConversationRead)
+	mux.HandleFunc("GET /api/notifications", apiCfg.HandleGetNotifications)
+	mux.HandleFunc("GET /api/notifications/unread_count", apiCfg.HandleGetUnreadNotificationCount)
+	mux.HandleFunc("POST /api/notifications/read", apiCfg.HandleMarkAllNotificationsRead)
+	mux.HandleFunc("POST /api/notifications/{notificationID}/read", apiCfg.HandleMarkNotificationRead)
+	mux.HandleFunc("GET /api/tags/trending", apiCfg.HandleGetTrendingTags)
+	mux.HandleFunc("GET /api/tags/{tag}/chirps", apiCfg.HandleGetChirpsByTag)
 	// Webhook
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.HandlePolkaHook)
 
@@ -0,0 +1,54 @@
+-- name: BlockUser :exec
+INSERT INTO blocks(blocker_id, blocked_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :exec
+DELETE FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: IsBlockedBetween :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocker_id = sqlc.arg('user_a') AND blocked_id = sqlc.arg('user_b'))
+        OR (blocker_id = sqlc.arg('user_b') AND blocked_id = sqlc.arg('user_a'))
+);
+
+-- name: LockUserPair :exec
+SELECT pg_advisory_xact_lock(hashtextextended(LEAST(sqlc.arg('user_a')::uuid, sqlc.arg('user_b')::uuid)::text || GREATEST(sqlc.arg('user_a')::uuid, sqlc.arg('user_b')::uuid)::text, 0));
+
+-- name: IsBlockedWithAny :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocker_id = sqlc.arg('user_id') AND blocked_id = ANY(sqlc.arg('other_ids')::uuid[]))
+        OR (blocker_id = ANY(sqlc.arg('other_ids')::uuid[]) AND blocked_id = sqlc.arg('user_id'))
+);
+
+-- name: DeleteFollowsBetween :exec
+DELETE FROM follows
+WHERE (follower_id = sqlc.arg('user_a') AND followee_id = sqlc.arg('user_b'))
+    OR (follower_id = sqlc.arg('user_b') AND followee_id = sqlc.arg('user_a'));
+
+-- name: DeleteFollowRequestsBetween :exec
+DELETE FROM follow_requests
+WHERE (requester_id = sqlc.arg('user_a') AND target_id = sqlc.arg('user_b'))
+    OR (requester_id = sqlc.arg('user_b') AND target_id = sqlc.arg('user_a'));
+
+-- name: MuteUser :exec
+INSERT INTO mutes(muter_id, muted_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :exec
+DELETE FROM mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: GetHiddenUserIDs :many
+SELECT blocked_id FROM blocks
+WHERE blocker_id = sqlc.arg('user_id')
+UNION
+SELECT blocker_id FROM blocks
+WHERE blocked_id = sqlc.arg('user_id')
+UNION
+SELECT muted_id FROM mutes
+WHERE muter_id = sqlc.arg('user_id');
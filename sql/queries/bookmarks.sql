@@ -0,0 +1,26 @@
+-- name: CreateBookmark :exec
+INSERT INTO bookmarks(user_id, chirp_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBookmark :exec
+DELETE FROM bookmarks
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetBookmarkedChirpIDs :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = sqlc.arg('user_id')
+    AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: GetBookmarks :many
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = sqlc.arg('user_id')
+    AND chirps.deleted_at IS NULL
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (bookmarks.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg('user_id'))
+ORDER BY bookmarks.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('page_limit');
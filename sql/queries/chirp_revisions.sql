@@ -0,0 +1,8 @@
+-- name: CreateChirpRevision :exec
+INSERT INTO chirp_revisions(chirp_id, body, created_at)
+VALUES ($1, $2, $3);
+
+-- name: GetChirpRevisions :many
+SELECT * FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY replaced_at DESC;
@@ -1,25 +1,113 @@
 -- name: CreateChirp :one
-INSERT INTO chirps(body, user_id)
-VALUES ($1,$2)
+INSERT INTO chirps(body, user_id, in_reply_to_id, quote_of_id, visibility)
+VALUES ($1,$2,$3,$4,$5)
 RETURNING *;
 
 -- name: GetAllChirps :many
 SELECT * FROM chirps
-ORDER BY
-    CASE WHEN COALESCE($1, 'asc') = 'desc' THEN created_at END DESC,
-    CASE WHEN COALESCE($1, 'asc') = 'asc' THEN created_at END ASC;
+WHERE deleted_at IS NULL
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetAllChirpsDesc :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
 
 -- name: GetChirpByID :one
 SELECT * FROM chirps
 WHERE id = $1;
 
+-- name: GetVisibleChirpByID :one
+SELECT * FROM chirps
+WHERE id = sqlc.arg('id')
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid);
+
+-- name: GetChirpViewers :many
+SELECT viewer_id::uuid FROM chirps, UNNEST(sqlc.arg('user_ids')::uuid[]) AS viewer_id
+WHERE chirps.id = sqlc.arg('chirp_id')
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, viewer_id);
+
+-- name: GetVisibleChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+    AND deleted_at IS NULL
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid);
+
+-- name: GetChirpByIDForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1
+FOR UPDATE;
+
 -- name: DeleteChirpById :exec
 DELETE FROM chirps
 WHERE id = $1;
 
+-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '',
+    deleted_at = NOW(),
+    pinned_at = NULL
+WHERE id = $1;
+
 -- name: GetChirpsByAuthorID :many
-SELECT * FROM chirps
-WHERE user_id = $1
-ORDER BY
-    CASE WHEN COALESCE($2, 'asc') = 'desc' THEN created_at END DESC,
-    CASE WHEN COALESCE($2, 'asc') = 'asc' THEN created_at END ASC;
+SELECT sqlc.embed(chirps), feed.item_id, feed.item_created_at, feed.rechirped_by
+FROM (
+    SELECT authored.id AS item_id, authored.created_at AS item_created_at, authored.id AS chirp_id, NULL::uuid AS rechirped_by
+    FROM chirps authored
+    WHERE authored.user_id = sqlc.arg('user_id')
+        AND authored.pinned_at IS NULL
+    UNION ALL
+    SELECT rechirps.id, rechirps.created_at, rechirps.chirp_id, rechirps.user_id
+    FROM rechirps
+    WHERE rechirps.user_id = sqlc.arg('user_id')
+) feed
+JOIN chirps ON chirps.id = feed.chirp_id
+WHERE chirps.deleted_at IS NULL
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (feed.item_created_at, feed.item_id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+ORDER BY feed.item_created_at ASC, feed.item_id ASC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetChirpsByAuthorIDDesc :many
+SELECT sqlc.embed(chirps), feed.item_id, feed.item_created_at, feed.rechirped_by
+FROM (
+    SELECT authored.id AS item_id, authored.created_at AS item_created_at, authored.id AS chirp_id, NULL::uuid AS rechirped_by
+    FROM chirps authored
+    WHERE authored.user_id = sqlc.arg('user_id')
+        AND authored.pinned_at IS NULL
+    UNION ALL
+    SELECT rechirps.id, rechirps.created_at, rechirps.chirp_id, rechirps.user_id
+    FROM rechirps
+    WHERE rechirps.user_id = sqlc.arg('user_id')
+) feed
+JOIN chirps ON chirps.id = feed.chirp_id
+WHERE chirps.deleted_at IS NULL
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (feed.item_created_at, feed.item_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+ORDER BY feed.item_created_at DESC, feed.item_id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
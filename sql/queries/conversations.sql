@@ -0,0 +1,84 @@
+-- name: CreateConversation :one
+INSERT INTO conversations(created_by, is_group)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: AddConversationParticipants :exec
+INSERT INTO conversation_participants(conversation_id, user_id)
+SELECT sqlc.arg('conversation_id')::uuid, unnest(sqlc.arg('user_ids')::uuid[]);
+
+-- name: GetDirectConversation :one
+SELECT conversations.* FROM conversations
+WHERE NOT conversations.is_group
+    AND EXISTS (
+        SELECT 1 FROM conversation_participants
+        WHERE conversation_participants.conversation_id = conversations.id
+            AND conversation_participants.user_id = sqlc.arg('user_a')
+    )
+    AND EXISTS (
+        SELECT 1 FROM conversation_participants
+        WHERE conversation_participants.conversation_id = conversations.id
+            AND conversation_participants.user_id = sqlc.arg('user_b')
+    )
+LIMIT 1;
+
+-- name: GetConversationForUser :one
+SELECT conversations.* FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversations.id = $1
+    AND conversation_participants.user_id = $2;
+
+-- name: GetConversationParticipants :many
+SELECT * FROM conversation_participants
+WHERE conversation_id = $1
+ORDER BY joined_at, user_id;
+
+-- name: GetConversationsForUser :many
+SELECT sqlc.embed(conversations),
+    (
+        SELECT COUNT(*) FROM messages
+        WHERE messages.conversation_id = conversations.id
+            AND messages.sender_id <> sqlc.arg('user_id')
+            AND (
+                conversation_participants.last_read_at IS NULL
+                OR messages.created_at > conversation_participants.last_read_at
+            )
+    ) AS unread_count
+FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversation_participants.user_id = sqlc.arg('user_id')
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (conversations.updated_at, conversations.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_participants
+SET last_read_at = NOW()
+WHERE conversation_id = $1 AND user_id = $2;
+
+-- name: CreateMessage :one
+INSERT INTO messages(conversation_id, sender_id, body)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetMessages :many
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg('conversation_id')
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: CountUsersByIDs :one
+SELECT COUNT(*) FROM users
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
@@ -0,0 +1,33 @@
+-- name: CreateDraft :one
+INSERT INTO drafts(user_id, body, in_reply_to_id, quote_of_id, visibility)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetDraftForUser :one
+SELECT * FROM drafts
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetDraftsForUser :many
+SELECT * FROM drafts
+WHERE user_id = sqlc.arg('user_id')
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET body = $3,
+    in_reply_to_id = $4,
+    quote_of_id = $5,
+    visibility = $6,
+    updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteDraft :one
+DELETE FROM drafts
+WHERE id = $1 AND user_id = $2
+RETURNING *;
@@ -0,0 +1,32 @@
+-- name: CreateFollowRequest :execrows
+INSERT INTO follow_requests(requester_id, target_id)
+SELECT sqlc.arg('requester_id')::uuid, sqlc.arg('target_id')::uuid
+WHERE NOT EXISTS (
+    SELECT 1 FROM follows
+    WHERE follower_id = sqlc.arg('requester_id') AND followee_id = sqlc.arg('target_id')
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFollowRequest :execrows
+DELETE FROM follow_requests
+WHERE requester_id = $1 AND target_id = $2;
+
+-- name: GetFollowRequests :many
+SELECT requester_id AS user_id, created_at AS requested_at FROM follow_requests
+WHERE target_id = sqlc.arg('target_id')
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, requester_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY created_at DESC, requester_id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: AcceptAllFollowRequests :exec
+WITH accepted AS (
+    DELETE FROM follow_requests
+    WHERE target_id = $1
+    RETURNING requester_id, target_id
+)
+INSERT INTO follows(follower_id, followee_id)
+SELECT requester_id, target_id FROM accepted
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,72 @@
+-- name: FollowUser :execrows
+INSERT INTO follows(follower_id, followee_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: GetFolloweeIDs :many
+SELECT followee_id FROM follows
+WHERE follower_id = $1;
+
+-- name: UnfollowUser :exec
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetFollowers :many
+SELECT follower_id AS user_id, created_at AS followed_at FROM follows
+WHERE followee_id = sqlc.arg('user_id')
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, follower_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY created_at DESC, follower_id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetFollowing :many
+SELECT followee_id AS user_id, created_at AS followed_at FROM follows
+WHERE follower_id = sqlc.arg('user_id')
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, followee_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY created_at DESC, followee_id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetTimeline :many
+SELECT sqlc.embed(chirps), feed.item_id, feed.item_created_at, feed.rechirped_by
+FROM (
+    SELECT authored.id AS item_id, authored.created_at AS item_created_at, authored.id AS chirp_id, NULL::uuid AS rechirped_by
+    FROM chirps authored
+    WHERE authored.user_id IN (
+        SELECT followee_id FROM follows
+        WHERE follower_id = sqlc.arg('user_id')
+    )
+    UNION ALL
+    SELECT rechirps.id, rechirps.created_at, rechirps.chirp_id, rechirps.user_id
+    FROM rechirps
+    WHERE rechirps.user_id IN (
+        SELECT followee_id FROM follows
+        WHERE follower_id = sqlc.arg('user_id')
+    )
+) feed
+JOIN chirps ON chirps.id = feed.chirp_id
+WHERE chirps.deleted_at IS NULL
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (feed.item_created_at, feed.item_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg('user_id'))
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.arg('user_id')
+            AND mutes.muted_id = chirps.user_id
+    )
+    AND (
+        feed.rechirped_by IS NULL
+        OR NOT EXISTS (
+            SELECT 1 FROM mutes
+            WHERE mutes.muter_id = sqlc.arg('user_id')
+                AND mutes.muted_id = feed.rechirped_by
+        )
+    )
+ORDER BY feed.item_created_at DESC, feed.item_id DESC
+LIMIT sqlc.arg('page_limit');
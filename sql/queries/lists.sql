@@ -0,0 +1,75 @@
+-- name: CreateList :one
+INSERT INTO lists(user_id, name)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetListForUser :one
+SELECT * FROM lists
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetListsForUser :many
+SELECT * FROM lists
+WHERE user_id = sqlc.arg('user_id')
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: RenameList :one
+UPDATE lists
+SET name = $3,
+    updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteList :execrows
+DELETE FROM lists
+WHERE id = $1 AND user_id = $2;
+
+-- name: AddListMember :exec
+INSERT INTO list_members(list_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveListMember :exec
+DELETE FROM list_members
+WHERE list_id = $1 AND user_id = $2;
+
+-- name: GetListMembers :many
+SELECT user_id, created_at AS added_at FROM list_members
+WHERE list_id = sqlc.arg('list_id')
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, user_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY created_at DESC, user_id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetListTimeline :many
+SELECT sqlc.embed(chirps), feed.item_id, feed.item_created_at, feed.rechirped_by
+FROM (
+    SELECT authored.id AS item_id, authored.created_at AS item_created_at, authored.id AS chirp_id, NULL::uuid AS rechirped_by
+    FROM chirps authored
+    WHERE authored.user_id IN (
+        SELECT user_id FROM list_members
+        WHERE list_id = sqlc.arg('list_id')
+    )
+    UNION ALL
+    SELECT rechirps.id, rechirps.created_at, rechirps.chirp_id, rechirps.user_id
+    FROM rechirps
+    WHERE rechirps.user_id IN (
+        SELECT user_id FROM list_members
+        WHERE list_id = sqlc.arg('list_id')
+    )
+) feed
+JOIN chirps ON chirps.id = feed.chirp_id
+WHERE chirps.deleted_at IS NULL
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (feed.item_created_at, feed.item_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+ORDER BY feed.item_created_at DESC, feed.item_id DESC
+LIMIT sqlc.arg('page_limit');
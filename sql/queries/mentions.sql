@@ -0,0 +1,38 @@
+-- name: GetUsersByHandles :many
+SELECT id, handle FROM users
+WHERE LOWER(handle) = ANY(sqlc.arg('handles')::text[])
+    AND NOT blocked_between(users.id, sqlc.arg('author_id')::uuid);
+
+-- name: CreateMentions :exec
+INSERT INTO mentions(chirp_id, user_id, start_offset, end_offset)
+SELECT sqlc.arg('chirp_id')::uuid,
+    unnest(sqlc.arg('user_ids')::uuid[]),
+    unnest(sqlc.arg('start_offsets')::int[]),
+    unnest(sqlc.arg('end_offsets')::int[]);
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM mentions
+WHERE chirp_id = $1;
+
+-- name: GetMentionsForChirps :many
+SELECT mentions.chirp_id, mentions.user_id, users.handle, mentions.start_offset, mentions.end_offset
+FROM mentions
+JOIN users ON users.id = mentions.user_id
+WHERE mentions.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY mentions.chirp_id, mentions.start_offset;
+
+-- name: GetChirpsMentioningUser :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+    AND EXISTS (
+        SELECT 1 FROM mentions
+        WHERE mentions.chirp_id = chirps.id
+            AND mentions.user_id = sqlc.arg('user_id')
+    )
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg('user_id'))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
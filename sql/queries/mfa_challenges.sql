@@ -0,0 +1,22 @@
+-- name: CreateMFAChallenge :exec
+INSERT INTO mfa_challenges(token, user_id, device_name, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetMFAChallengeForUpdate :one
+SELECT * FROM mfa_challenges
+WHERE token = $1
+    AND expires_at > NOW()
+FOR UPDATE;
+
+-- name: IncrementMFAChallengeAttempts :exec
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE token = $1;
+
+-- name: DeleteMFAChallenge :exec
+DELETE FROM mfa_challenges
+WHERE token = $1;
+
+-- name: DeleteExpiredMFAChallenges :exec
+DELETE FROM mfa_challenges
+WHERE expires_at <= NOW();
@@ -0,0 +1,68 @@
+-- name: CreateNotification :one
+INSERT INTO notifications(user_id, actor_id, type, chirp_id)
+SELECT sqlc.arg('user_id')::uuid, sqlc.narg('actor_id')::uuid, sqlc.arg('type')::text, sqlc.narg('chirp_id')::uuid
+WHERE sqlc.narg('actor_id')::uuid IS NULL
+    OR (
+        NOT blocked_between(sqlc.arg('user_id')::uuid, sqlc.narg('actor_id')::uuid)
+        AND NOT EXISTS (
+            SELECT 1 FROM mutes
+            WHERE mutes.muter_id = sqlc.arg('user_id')::uuid
+                AND mutes.muted_id = sqlc.narg('actor_id')::uuid
+        )
+    )
+RETURNING *;
+
+-- name: GetNotifications :many
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg('user_id')
+    AND (NOT sqlc.arg('unread_only')::boolean OR read_at IS NULL)
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+    AND (
+        actor_id IS NULL
+        OR (
+            NOT EXISTS (
+                SELECT 1 FROM mutes
+                WHERE mutes.muter_id = notifications.user_id
+                    AND mutes.muted_id = notifications.actor_id
+            )
+            AND NOT EXISTS (
+                SELECT 1 FROM blocks
+                WHERE blocks.blocker_id = notifications.user_id
+                    AND blocks.blocked_id = notifications.actor_id
+            )
+        )
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL
+    AND (
+        actor_id IS NULL
+        OR (
+            NOT EXISTS (
+                SELECT 1 FROM mutes
+                WHERE mutes.muter_id = notifications.user_id
+                    AND mutes.muted_id = notifications.actor_id
+            )
+            AND NOT EXISTS (
+                SELECT 1 FROM blocks
+                WHERE blocks.blocker_id = notifications.user_id
+                    AND blocks.blocked_id = notifications.actor_id
+            )
+        )
+    );
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications
+SET read_at = COALESCE(read_at, NOW())
+WHERE id = $1 AND user_id = $2;
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
@@ -0,0 +1,21 @@
+-- name: CountPinnedChirps :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1 AND pinned_at IS NOT NULL;
+
+-- name: PinChirp :exec
+UPDATE chirps
+SET pinned_at = NOW()
+WHERE id = $1 AND pinned_at IS NULL;
+
+-- name: UnpinChirp :exec
+UPDATE chirps
+SET pinned_at = NULL
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetPinnedChirps :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+    AND pinned_at IS NOT NULL
+    AND deleted_at IS NULL
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+ORDER BY pinned_at DESC, id DESC;
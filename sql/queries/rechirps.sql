@@ -0,0 +1,12 @@
+-- name: CreateRechirp :exec
+INSERT INTO rechirps(user_id, chirp_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteRechirp :exec
+DELETE FROM rechirps
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: DeleteRechirpsOfChirp :exec
+DELETE FROM rechirps
+WHERE chirp_id = $1;
@@ -0,0 +1,18 @@
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes(user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: GetUnusedRecoveryCodes :many
+SELECT * FROM recovery_codes
+WHERE user_id = $1
+    AND used_at IS NULL;
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE id = $1
+    AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
@@ -1,6 +1,6 @@
 -- name: CreateRefreshToken :exec
-INSERT INTO refresh_tokens(token, user_id, expires_at, revoked_at)
-VALUES($1, $2, $3, $4);
+INSERT INTO refresh_tokens(token, user_id, expires_at, revoked_at, family_id, device_name, user_agent, ip, access_token_jti, access_token_expires_at)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
 
 -- name: GetRefreshToken :one
 SELECT * FROM refresh_tokens
@@ -11,3 +11,67 @@ UPDATE refresh_tokens
 SET revoked_at = NOW(),
     updated_at = NOW()
 WHERE token = $1;
+
+-- name: RotateRefreshToken :one
+WITH rotated AS (
+    UPDATE refresh_tokens
+    SET revoked_at = NOW(),
+        rotated_at = NOW(),
+        updated_at = NOW()
+    WHERE token = sqlc.arg('old_token')
+        AND revoked_at IS NULL
+        AND expires_at > NOW()
+    RETURNING user_id, family_id, device_name
+)
+INSERT INTO refresh_tokens(token, user_id, expires_at, family_id, device_name, user_agent, ip)
+SELECT sqlc.arg('new_token')::text, user_id, sqlc.arg('expires_at')::timestamp, family_id, device_name, sqlc.arg('user_agent')::text, sqlc.arg('ip')::text
+FROM rotated
+RETURNING *;
+
+-- name: SetRefreshTokenAccessToken :exec
+UPDATE refresh_tokens
+SET access_token_jti = $2,
+    access_token_expires_at = $3
+WHERE token = $1;
+
+-- name: GetFamilyIDByAccessTokenJTI :one
+SELECT family_id FROM refresh_tokens
+WHERE access_token_jti = $1;
+
+-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: GetSessionsForUser :many
+SELECT family_id, device_name, user_agent, ip, last_used_at, expires_at,
+    (
+        SELECT MIN(family.created_at) FROM refresh_tokens family
+        WHERE family.family_id = refresh_tokens.family_id
+    )::timestamp AS started_at
+FROM refresh_tokens
+WHERE user_id = $1
+    AND revoked_at IS NULL
+    AND expires_at > NOW()
+ORDER BY last_used_at DESC, family_id DESC;
+
+-- name: RevokeSession :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeAllSessions :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserSessionsExcept :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = sqlc.arg('user_id')
+    AND revoked_at IS NULL
+    AND family_id IS DISTINCT FROM sqlc.narg('keep_family_id')::uuid;
@@ -0,0 +1,40 @@
+-- name: IncrementReplyCount :exec
+UPDATE chirps
+SET reply_count = reply_count + 1
+WHERE id = $1;
+
+-- name: DecrementReplyCount :exec
+UPDATE chirps
+SET reply_count = GREATEST(reply_count - 1, 0)
+WHERE id = $1;
+
+-- name: GetChirpReplies :many
+SELECT * FROM chirps
+WHERE in_reply_to_id = sqlc.arg('chirp_id')
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetChirpThread :many
+WITH RECURSIVE ancestors AS (
+    SELECT chirps.id, chirps.in_reply_to_id FROM chirps
+    WHERE chirps.id = sqlc.arg('chirp_id')
+    UNION ALL
+    SELECT parent.id, parent.in_reply_to_id FROM chirps parent
+    JOIN ancestors ON parent.id = ancestors.in_reply_to_id
+),
+thread AS (
+    SELECT chirps.*, 0 AS depth, ARRAY[chirps.created_at] AS path FROM chirps
+    WHERE chirps.id = (SELECT ancestors.id FROM ancestors WHERE ancestors.in_reply_to_id IS NULL)
+    UNION ALL
+    SELECT reply.*, thread.depth + 1, thread.path || reply.created_at FROM chirps reply
+    JOIN thread ON reply.in_reply_to_id = thread.id
+)
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, reply_count, deleted_at, like_count, quote_of_id, visibility, pinned_at, depth::int AS depth
+FROM thread
+WHERE chirp_visible_to(thread.id, thread.user_id, thread.visibility, sqlc.narg('viewer_id')::uuid)
+ORDER BY path, id;
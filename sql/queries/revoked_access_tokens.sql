@@ -0,0 +1,32 @@
+-- name: RevokeAccessToken :exec
+INSERT INTO revoked_access_tokens(jti, user_id, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: RevokeSessionAccessTokens :many
+INSERT INTO revoked_access_tokens(jti, user_id, expires_at)
+SELECT access_token_jti, user_id, access_token_expires_at FROM refresh_tokens
+WHERE family_id = $1
+    AND access_token_jti IS NOT NULL
+    AND access_token_expires_at > NOW()
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: RevokeUserAccessTokens :many
+INSERT INTO revoked_access_tokens(jti, user_id, expires_at)
+SELECT access_token_jti, user_id, access_token_expires_at FROM refresh_tokens
+WHERE user_id = sqlc.arg('user_id')
+    AND family_id IS DISTINCT FROM sqlc.narg('keep_family_id')::uuid
+    AND access_token_jti IS NOT NULL
+    AND access_token_expires_at > NOW()
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetRevokedAccessTokensSince :many
+SELECT jti, expires_at, revoked_at FROM revoked_access_tokens
+WHERE revoked_at > $1
+    AND expires_at > NOW();
+
+-- name: DeleteExpiredRevokedAccessTokens :exec
+DELETE FROM revoked_access_tokens
+WHERE expires_at <= NOW();
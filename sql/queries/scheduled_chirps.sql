@@ -0,0 +1,46 @@
+-- name: CreateScheduledChirp :one
+INSERT INTO scheduled_chirps(user_id, body, in_reply_to_id, quote_of_id, visibility, publish_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetScheduledChirpsForUser :many
+SELECT * FROM scheduled_chirps
+WHERE user_id = sqlc.arg('user_id')
+    AND (
+        sqlc.narg('cursor_publish_at')::timestamptz IS NULL
+        OR (publish_at, id) > (sqlc.narg('cursor_publish_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY publish_at ASC, id ASC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetScheduledChirpForUpdate :one
+SELECT * FROM scheduled_chirps
+WHERE id = $1 AND user_id = $2
+FOR UPDATE;
+
+-- name: UpdateScheduledChirp :one
+UPDATE scheduled_chirps
+SET body = $3,
+    visibility = $4,
+    publish_at = $5,
+    failure = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteScheduledChirp :execrows
+DELETE FROM scheduled_chirps
+WHERE id = $1 AND user_id = $2;
+
+-- name: ClaimDueScheduledChirp :one
+SELECT * FROM scheduled_chirps
+WHERE publish_at <= NOW() AND failure IS NULL
+ORDER BY publish_at ASC, id ASC
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkScheduledChirpFailed :exec
+UPDATE scheduled_chirps
+SET failure = $2,
+    updated_at = NOW()
+WHERE id = $1;
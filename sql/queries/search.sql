@@ -0,0 +1,30 @@
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps), ts_rank(search_vector, to_tsquery('english', sqlc.arg('query')))::real AS rank
+FROM chirps
+WHERE search_vector @@ to_tsquery('english', sqlc.arg('query'))
+    AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+    AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since'))
+    AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until'))
+    AND (
+        sqlc.narg('cursor_rank')::real IS NULL
+        OR (ts_rank(search_vector, to_tsquery('english', sqlc.arg('query')))::real, created_at, id)
+            < (sqlc.narg('cursor_rank')::real, sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: SearchChirpsRecent :many
+SELECT sqlc.embed(chirps), ts_rank(search_vector, to_tsquery('english', sqlc.arg('query')))::real AS rank
+FROM chirps
+WHERE search_vector @@ to_tsquery('english', sqlc.arg('query'))
+    AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+    AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since'))
+    AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until'))
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
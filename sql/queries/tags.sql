@@ -0,0 +1,40 @@
+-- name: CreateTags :exec
+INSERT INTO tags(name)
+SELECT unnest(sqlc.arg('names')::text[])
+ON CONFLICT (name) DO NOTHING;
+
+-- name: AddChirpTags :exec
+INSERT INTO chirp_tags(chirp_id, tag_id)
+SELECT sqlc.arg('chirp_id')::uuid, tags.id FROM tags
+WHERE tags.name = ANY(sqlc.arg('names')::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveChirpTagsExcept :exec
+DELETE FROM chirp_tags
+WHERE chirp_id = sqlc.arg('chirp_id')
+    AND tag_id NOT IN (
+        SELECT tags.id FROM tags
+        WHERE tags.name = ANY(sqlc.arg('names')::text[])
+    );
+
+-- name: GetChirpsByTag :many
+SELECT chirps.* FROM chirps
+JOIN chirp_tags ON chirp_tags.chirp_id = chirps.id
+JOIN tags ON tags.id = chirp_tags.tag_id
+WHERE tags.name = sqlc.arg('name')
+    AND chirps.deleted_at IS NULL
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetTrendingTags :many
+SELECT tags.name, COUNT(*) AS uses FROM chirp_tags
+JOIN tags ON tags.id = chirp_tags.tag_id
+WHERE chirp_tags.created_at >= sqlc.arg('since')
+GROUP BY tags.name
+ORDER BY uses DESC, tags.name ASC
+LIMIT sqlc.arg('page_limit');
@@ -1,6 +1,6 @@
 -- name: CreateUser :one
-INSERT INTO users(email, hashed_password)
-VALUES ($1, $2)
+INSERT INTO users(email, hashed_password, handle, display_name, bio, avatar_url)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 
@@ -11,10 +11,15 @@ WHERE email = $1;
 
 -- name: UpdateUser :one
 UPDATE users
-SET email = $2,
-    hashed_password = $3,
+SET email = COALESCE(sqlc.narg('email'), email),
+    hashed_password = COALESCE(sqlc.narg('hashed_password'), hashed_password),
+    handle = COALESCE(sqlc.narg('handle'), handle),
+    display_name = COALESCE(sqlc.narg('display_name'), display_name),
+    bio = COALESCE(sqlc.narg('bio'), bio),
+    avatar_url = COALESCE(sqlc.narg('avatar_url'), avatar_url),
+    is_private = COALESCE(sqlc.narg('is_private'), is_private),
     updated_at = NOW()
-WHERE id = $1
+WHERE id = sqlc.arg('id')
 RETURNING *;
 
 -- name: UpgradeUserToRed :one
@@ -22,3 +27,91 @@ UPDATE users
 SET is_chirpy_red = TRUE
 WHERE id = $1
 RETURNING *;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUserByIDForUpdate :one
+SELECT * FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetUserProfileByHandle :one
+SELECT users.id, users.created_at, users.handle, users.display_name, users.bio, users.avatar_url, users.is_chirpy_red, users.is_private,
+    (
+        SELECT COUNT(*) FROM chirps
+        WHERE chirps.user_id = users.id
+            AND chirps.deleted_at IS NULL
+    ) AS chirp_count,
+    (
+        SELECT COUNT(*) FROM follows
+        WHERE follows.followee_id = users.id
+    ) AS follower_count,
+    (
+        SELECT COUNT(*) FROM follows
+        WHERE follows.follower_id = users.id
+    ) AS following_count
+FROM users
+WHERE LOWER(users.handle) = LOWER(sqlc.arg('handle'));
+
+-- name: GetUsersTokensValidAfterSince :many
+SELECT id, tokens_valid_after FROM users
+WHERE tokens_valid_after > $1;
+
+-- name: SetUserTokensValidAfter :exec
+UPDATE users
+SET tokens_valid_after = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetUserTOTPSecret :execrows
+UPDATE users
+SET totp_secret = $2,
+    updated_at = NOW()
+WHERE id = $1
+    AND NOT totp_enabled;
+
+-- name: EnableUserTOTP :exec
+UPDATE users
+SET totp_enabled = TRUE,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetUserTOTPLastStep :exec
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1;
+
+-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL,
+    totp_enabled = FALSE,
+    totp_last_step = 0,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: RecordMFAFailure :exec
+UPDATE users
+SET mfa_failed_attempts = $2,
+    mfa_locked_until = $3
+WHERE id = $1;
+
+-- name: ResetMFAFailures :exec
+UPDATE users
+SET mfa_failed_attempts = 0,
+    mfa_locked_until = NULL
+WHERE id = $1;
+
+-- name: SuspendUser :exec
+UPDATE users
+SET suspended_at = NOW(),
+    tokens_valid_after = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: UnsuspendUser :execrows
+UPDATE users
+SET suspended_at = NULL,
+    updated_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE chirp_revisions(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    chirp_id UUID NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    replaced_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_revisions_chirp_id_idx ON chirp_revisions(chirp_id, replaced_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chirp_revisions;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chirps
+ADD COLUMN in_reply_to_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX chirps_in_reply_to_id_idx ON chirps(in_reply_to_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chirps
+DROP COLUMN deleted_at,
+DROP COLUMN reply_count,
+DROP COLUMN in_reply_to_id;
+-- +goose StatementEnd
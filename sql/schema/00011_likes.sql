@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE likes(
+    user_id UUID NOT NULL,
+    chirp_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    UNIQUE (user_id, chirp_id),
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX likes_user_id_created_at_idx ON likes(user_id, created_at);
+
+ALTER TABLE chirps
+ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chirps
+DROP COLUMN like_count;
+
+DROP TABLE likes;
+-- +goose StatementEnd
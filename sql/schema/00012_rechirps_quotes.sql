@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE rechirps(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    chirp_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    UNIQUE (user_id, chirp_id),
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX rechirps_user_id_created_at_id_idx ON rechirps(user_id, created_at, id);
+
+ALTER TABLE chirps
+ADD COLUMN quote_of_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chirps
+DROP COLUMN quote_of_id;
+
+DROP TABLE rechirps;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE tags(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE chirp_tags(
+    chirp_id UUID NOT NULL,
+    tag_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (chirp_id, tag_id),
+
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE,
+
+    FOREIGN KEY (tag_id)
+    REFERENCES tags(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_tags_tag_id_idx ON chirp_tags(tag_id);
+CREATE INDEX chirp_tags_created_at_idx ON chirp_tags(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chirp_tags;
+DROP TABLE tags;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+ADD COLUMN handle TEXT;
+
+CREATE UNIQUE INDEX users_handle_lower_idx ON users(LOWER(handle));
+
+CREATE TABLE mentions(
+    chirp_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (chirp_id, start_offset),
+
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX mentions_user_id_idx ON mentions(user_id, chirp_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE mentions;
+
+DROP INDEX users_handle_lower_idx;
+
+ALTER TABLE users
+DROP COLUMN handle;
+-- +goose StatementEnd
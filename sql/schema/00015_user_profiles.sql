@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+ADD COLUMN display_name TEXT,
+ADD COLUMN bio TEXT,
+ADD COLUMN avatar_url TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+DROP COLUMN avatar_url,
+DROP COLUMN bio,
+DROP COLUMN display_name;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE notifications(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    actor_id UUID,
+    type TEXT NOT NULL CHECK (type IN ('like', 'reply', 'follow', 'mention', 'red_upgrade')),
+    chirp_id UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    read_at TIMESTAMP,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    FOREIGN KEY (actor_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX notifications_user_id_created_at_id_idx ON notifications(user_id, created_at, id);
+CREATE INDEX notifications_unread_idx ON notifications(user_id) WHERE read_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE notifications;
+-- +goose StatementEnd
@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE conversations(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_by UUID NOT NULL,
+    is_group BOOLEAN NOT NULL DEFAULT FALSE,
+
+    FOREIGN KEY (created_by)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE conversation_participants(
+    conversation_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    joined_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_read_at TIMESTAMP,
+
+    PRIMARY KEY (conversation_id, user_id),
+
+    FOREIGN KEY (conversation_id)
+    REFERENCES conversations(id)
+    ON DELETE CASCADE,
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX conversation_participants_user_id_idx ON conversation_participants(user_id);
+
+CREATE TABLE messages(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    conversation_id UUID NOT NULL,
+    sender_id UUID NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (conversation_id)
+    REFERENCES conversations(id)
+    ON DELETE CASCADE,
+
+    FOREIGN KEY (sender_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX messages_conversation_id_created_at_id_idx ON messages(conversation_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE messages;
+
+DROP TABLE conversation_participants;
+
+DROP TABLE conversations;
+-- +goose StatementEnd
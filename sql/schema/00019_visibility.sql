@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+CHECK (visibility IN ('public', 'followers', 'mentioned'));
+
+ALTER TABLE users
+ADD COLUMN is_private BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE follow_requests(
+    requester_id UUID NOT NULL,
+    target_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (requester_id, target_id),
+    CHECK (requester_id <> target_id),
+
+    FOREIGN KEY (requester_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    FOREIGN KEY (target_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX follow_requests_target_id_idx ON follow_requests(target_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE follow_requests;
+
+ALTER TABLE users
+DROP COLUMN is_private;
+
+ALTER TABLE chirps
+DROP COLUMN visibility;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chirps
+ADD COLUMN pinned_at TIMESTAMP;
+
+CREATE INDEX chirps_pinned_idx ON chirps(user_id, pinned_at DESC)
+WHERE pinned_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX chirps_pinned_idx;
+
+ALTER TABLE chirps
+DROP COLUMN pinned_at;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Scheduled chirps are kept apart from chirps until they go live, so no
+-- listing has to know about them. publish_at carries its time zone because
+-- clients send it in their own.
+CREATE TABLE scheduled_chirps(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    body TEXT NOT NULL,
+    in_reply_to_id UUID,
+    quote_of_id UUID,
+    visibility TEXT NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'followers', 'mentioned')),
+    publish_at TIMESTAMPTZ NOT NULL,
+    failure TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX scheduled_chirps_due_idx ON scheduled_chirps(publish_at) WHERE failure IS NULL;
+
+CREATE INDEX scheduled_chirps_user_id_publish_at_idx ON scheduled_chirps(user_id, publish_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE scheduled_chirps;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every refresh rotates the token; the tokens descending from one login
+-- share a family so the whole chain can be revoked when a used token
+-- turns up again. Existing tokens each start a family of their own.
+ALTER TABLE refresh_tokens
+ADD COLUMN family_id UUID NOT NULL DEFAULT uuid_generate_v4();
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens(family_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX refresh_tokens_family_id_idx;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN family_id;
+-- +goose StatementEnd
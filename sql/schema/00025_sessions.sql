@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A session is a refresh token family. Its live token carries what we
+-- know about the client it was issued to.
+ALTER TABLE refresh_tokens
+ADD COLUMN device_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+ADD COLUMN ip TEXT NOT NULL DEFAULT '',
+ADD COLUMN last_used_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens(user_id) WHERE revoked_at IS NULL;
+
+-- Access tokens issued before this moment are rejected.
+ALTER TABLE users
+ADD COLUMN tokens_valid_after TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+DROP COLUMN tokens_valid_after;
+
+DROP INDEX refresh_tokens_user_id_idx;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN last_used_at,
+DROP COLUMN ip,
+DROP COLUMN user_agent,
+DROP COLUMN device_name;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The access token last issued with each refresh token, so the access
+-- tokens of a session can be revoked along with it.
+ALTER TABLE refresh_tokens
+ADD COLUMN access_token_jti TEXT,
+ADD COLUMN access_token_expires_at TIMESTAMP;
+
+CREATE INDEX refresh_tokens_access_token_jti_idx ON refresh_tokens(access_token_jti);
+
+-- Access tokens revoked before they expire. Rows are only needed until
+-- expires_at and are pruned after that.
+CREATE TABLE revoked_access_tokens(
+    jti TEXT PRIMARY KEY,
+    user_id UUID NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX revoked_access_tokens_revoked_at_idx ON revoked_access_tokens(revoked_at);
+
+CREATE INDEX revoked_access_tokens_expires_at_idx ON revoked_access_tokens(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE revoked_access_tokens;
+
+DROP INDEX refresh_tokens_access_token_jti_idx;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN access_token_expires_at,
+DROP COLUMN access_token_jti;
+-- +goose StatementEnd
@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+-- totp_secret is set at enrolment and only used for sign-in once confirmed
+-- (totp_enabled). totp_last_step is the last time step a code was accepted
+-- for, so a code can't be replayed.
+ALTER TABLE users
+ADD COLUMN totp_secret TEXT,
+ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE recovery_codes(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX recovery_codes_user_id_idx ON recovery_codes(user_id);
+
+-- A password check that passed for a user with 2FA enabled, waiting for
+-- the second factor.
+CREATE TABLE mfa_challenges(
+    token TEXT PRIMARY KEY,
+    user_id UUID NOT NULL,
+    device_name TEXT NOT NULL DEFAULT '',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX mfa_challenges_expires_at_idx ON mfa_challenges(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE mfa_challenges;
+
+DROP TABLE recovery_codes;
+
+ALTER TABLE users
+DROP COLUMN totp_last_step,
+DROP COLUMN totp_enabled,
+DROP COLUMN totp_secret;
+-- +goose StatementEnd
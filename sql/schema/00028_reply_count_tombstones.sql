@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Deleting a reply that had replies of its own used to decrement its
+-- parent's reply_count even though the reply stayed as a tombstone. Remove
+-- tombstones left without replies, from the leaves up, and recount.
+DO $$
+BEGIN
+    LOOP
+        DELETE FROM chirps
+        WHERE deleted_at IS NOT NULL
+            AND NOT EXISTS (
+                SELECT 1 FROM chirps AS replies
+                WHERE replies.in_reply_to_id = chirps.id
+            );
+        EXIT WHEN NOT FOUND;
+    END LOOP;
+END
+$$;
+
+UPDATE chirps
+SET reply_count = (
+    SELECT COUNT(*) FROM chirps AS replies
+    WHERE replies.in_reply_to_id = chirps.id
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 1;
+-- +goose StatementEnd
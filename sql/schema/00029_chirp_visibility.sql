@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Whether either user has blocked the other.
+CREATE FUNCTION blocked_between(a UUID, b UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE
+AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = a AND blocks.blocked_id = b)
+            OR (blocks.blocker_id = b AND blocks.blocked_id = a)
+    );
+$$;
+
+-- Whether viewer may see a chirp: neither has blocked the other, and the
+-- chirp's visibility lets them see it. viewer is NULL for anonymous
+-- requests, which only see public chirps of public accounts. Every query
+-- that returns chirps to a viewer filters with this, so the rules live in
+-- one place.
+CREATE FUNCTION chirp_visible_to(chirp UUID, author UUID, chirp_visibility TEXT, viewer UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE
+AS $$
+    SELECT NOT blocked_between(author, viewer)
+        AND (
+            author = viewer
+            OR (
+                chirp_visibility = 'public'
+                AND NOT EXISTS (
+                    SELECT 1 FROM users
+                    WHERE users.id = author AND users.is_private
+                )
+            )
+            OR (
+                chirp_visibility <> 'mentioned'
+                AND EXISTS (
+                    SELECT 1 FROM follows
+                    WHERE follows.follower_id = viewer
+                        AND follows.followee_id = author
+                )
+            )
+            OR (
+                chirp_visibility = 'mentioned'
+                AND EXISTS (
+                    SELECT 1 FROM mentions
+                    WHERE mentions.chirp_id = chirp
+                        AND mentions.user_id = viewer
+                )
+            )
+        );
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION chirp_visible_to(UUID, UUID, TEXT, UUID);
+
+DROP FUNCTION blocked_between(UUID, UUID);
+-- +goose StatementEnd
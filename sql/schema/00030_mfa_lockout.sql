@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Consecutive wrong second-factor codes, across every way of entering one,
+-- and when the user may try again after too many.
+ALTER TABLE users
+ADD COLUMN mfa_failed_attempts INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN mfa_locked_until TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+DROP COLUMN mfa_locked_until,
+DROP COLUMN mfa_failed_attempts;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Suspended users can't log in and their tokens are revoked.
+ALTER TABLE users
+ADD COLUMN suspended_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+DROP COLUMN suspended_at;
+-- +goose StatementEnd
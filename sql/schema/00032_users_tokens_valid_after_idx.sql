@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every instance polls for recent revoke-alls.
+CREATE INDEX users_tokens_valid_after_idx ON users(tokens_valid_after) WHERE tokens_valid_after IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX users_tokens_valid_after_idx;
+-- +goose StatementEnd
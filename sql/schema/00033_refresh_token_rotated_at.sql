@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Set when a refresh hands out the token's successor, so reuse of a
+-- rotated token can be told apart from a token revoked by logging out.
+ALTER TABLE refresh_tokens
+ADD COLUMN rotated_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens
+DROP COLUMN rotated_at;
+-- +goose StatementEnd